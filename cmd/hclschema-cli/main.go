@@ -1,11 +1,13 @@
 package main
 
 import (
+	"bytes"
 	"encoding/json"
 	"flag"
 	"fmt"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/avestura/hcl-schema/pkg/hclschema"
 	"github.com/hashicorp/hcl/v2"
@@ -19,6 +21,22 @@ type OutDiagnostic struct {
 	EndCol    int    `json:"endCol"`
 	Severity  string `json:"severity"`
 	Message   string `json:"message"`
+
+	// Rule is the ID of the lint rule that reported the diagnostic.
+	Rule string `json:"rule,omitempty"`
+
+	Related []OutRelated `json:"related,omitempty"`
+}
+
+// OutRelated is a secondary location involved in a diagnostic, such as the
+// first of two duplicate blocks.
+type OutRelated struct {
+	File      string `json:"file"`
+	StartLine int    `json:"startLine"`
+	StartCol  int    `json:"startCol"`
+	EndLine   int    `json:"endLine"`
+	EndCol    int    `json:"endCol"`
+	Message   string `json:"message"`
 }
 
 func diagSeverity(d *hcl.Diagnostic) string {
@@ -33,6 +51,17 @@ func diagSeverity(d *hcl.Diagnostic) string {
 }
 
 func main() {
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "lint":
+			lint(os.Args[2:])
+			return
+		case "fmt":
+			format(os.Args[2:])
+			return
+		}
+	}
+
 	var detect bool
 	flag.BoolVar(&detect, "detect", true, "Detect schema via __schema attribute and validate")
 	flag.Parse()
@@ -56,6 +85,97 @@ func main() {
 		diags = hclschema.ValidateFileWithSchema(schema, hclPath)
 	}
 
+	emit(diags, hclPath)
+}
+
+// lint reports the lint findings of a schema file.
+func lint(args []string) {
+	fs := flag.NewFlagSet("lint", flag.ExitOnError)
+	var opts hclschema.LintOptions
+	fs.BoolVar(&opts.Exported, "exported", false, "The schema is imported by other schemas, so its ids are used")
+	fs.Parse(args)
+	if fs.NArg() != 1 {
+		fmt.Fprintln(os.Stderr, "usage: hclschema-cli lint [-exported] <schema-file>")
+		os.Exit(2)
+	}
+	schemaPath := fs.Arg(0)
+
+	emit(hclschema.LintSchemaFile(schemaPath, opts), schemaPath)
+}
+
+// format rewrites schema files in canonical layout. Directories are searched
+// for *.schema.hcl files. With -check, files are left untouched and the ones
+// that aren't formatted are listed, exiting with status 1 if there are any.
+func format(args []string) {
+	fs := flag.NewFlagSet("fmt", flag.ExitOnError)
+	var check bool
+	var opts hclschema.FormatOptions
+	fs.BoolVar(&check, "check", false, "List files that aren't formatted instead of rewriting them, and exit with status 1 if there are any")
+	fs.BoolVar(&opts.SortMembers, "sort", false, "Sort attributes, block headers and definitions by name")
+	fs.BoolVar(&opts.StripRedundantRequired, "strip-required", false, "Remove 'required = false' from attributes")
+	fs.BoolVar(&opts.NormalizeLabelNames, "label-names", false, "Write 'label_names' as a single line list")
+	fs.Parse(args)
+	if fs.NArg() == 0 {
+		fmt.Fprintln(os.Stderr, "usage: hclschema-cli fmt [-check] [-sort] [-strip-required] [-label-names] <schema-file-or-dir>...")
+		os.Exit(2)
+	}
+
+	var files []string
+	for _, arg := range fs.Args() {
+		err := filepath.WalkDir(arg, func(path string, d os.DirEntry, err error) error {
+			if err != nil {
+				return err
+			}
+			if !d.IsDir() && (path == arg || strings.HasSuffix(path, ".schema.hcl")) {
+				files = append(files, path)
+			}
+			return nil
+		})
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(2)
+		}
+	}
+
+	failed, unformatted := false, false
+	for _, path := range files {
+		src, err := os.ReadFile(path)
+		if err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+			continue
+		}
+		out, diags := hclschema.FormatSchema(src, path, opts)
+		if diags.HasErrors() {
+			fmt.Fprintln(os.Stderr, diags.Error())
+			failed = true
+			continue
+		}
+		if bytes.Equal(src, out) {
+			continue
+		}
+		if check {
+			fmt.Println(path)
+			unformatted = true
+			continue
+		}
+		if err := os.WriteFile(path, out, 0o644); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			failed = true
+		}
+	}
+
+	switch {
+	case failed:
+		os.Exit(2)
+	case unformatted:
+		os.Exit(1)
+	}
+}
+
+// emit writes diags to stdout as JSON. Diagnostics without a subject are
+// attributed to defaultFile.
+func emit(diags hcl.Diagnostics, defaultFile string) {
 	out := make([]OutDiagnostic, 0, len(diags))
 	for _, d := range diags {
 		if d == nil {
@@ -68,7 +188,7 @@ func main() {
 			endLine = d.Subject.End.Line - 1
 			endCol = d.Subject.End.Column - 1
 		}
-		file := hclPath
+		file := defaultFile
 		if d.Subject != nil && d.Subject.Filename != "" {
 			file = d.Subject.Filename
 		} else {
@@ -88,6 +208,23 @@ func main() {
 			}
 		}
 
+		var rule string
+		if f, ok := hcl.DiagnosticExtra[*hclschema.LintFinding](d); ok {
+			rule = f.Rule
+		}
+
+		var related []OutRelated
+		if rel, ok := hcl.DiagnosticExtra[*hclschema.RelatedRange](d); ok {
+			related = append(related, OutRelated{
+				File:      rel.Range.Filename,
+				StartLine: rel.Range.Start.Line - 1,
+				StartCol:  rel.Range.Start.Column - 1,
+				EndLine:   rel.Range.End.Line - 1,
+				EndCol:    rel.Range.End.Column - 1,
+				Message:   rel.Message,
+			})
+		}
+
 		out = append(out, OutDiagnostic{
 			File:      file,
 			StartLine: startLine,
@@ -96,6 +233,8 @@ func main() {
 			EndCol:    endCol,
 			Severity:  diagSeverity(d),
 			Message:   msg,
+			Rule:      rule,
+			Related:   related,
 		})
 	}
 
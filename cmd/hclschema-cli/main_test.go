@@ -2,8 +2,10 @@ package main
 
 import (
 	"encoding/json"
+	"os"
 	"os/exec"
 	"path/filepath"
+	"strings"
 	"testing"
 )
 
@@ -15,6 +17,7 @@ type CLIOutDiagnostic struct {
 	EndCol    int    `json:"endCol"`
 	Severity  string `json:"severity"`
 	Message   string `json:"message"`
+	Rule      string `json:"rule"`
 }
 
 func TestCLIReportsErrorForInvalidLinkedFile(t *testing.T) {
@@ -46,3 +49,71 @@ func TestCLIReportsErrorForInvalidLinkedFile(t *testing.T) {
 		t.Fatalf("expected at least one error severity diagnostic, got: %#v", diags)
 	}
 }
+
+func TestCLILintReportsRules(t *testing.T) {
+	schemaPath := filepath.Join("..", "..", "pkg", "hclschema", "testdata", "lint.schema.hcl")
+	cmd := exec.Command("go", "run", "./main.go", "lint", schemaPath)
+	cmd.Dir = "./"
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("lint failed: %v; output: %s", err, string(out))
+	}
+
+	var diags []CLIOutDiagnostic
+	if err := json.Unmarshal(out, &diags); err != nil {
+		t.Fatalf("failed to parse CLI output as JSON: %v; output: %s", err, string(out))
+	}
+
+	rules := make(map[string]string)
+	for _, d := range diags {
+		rules[d.Rule] = d.Severity
+	}
+	if rules["duplicate-attribute"] != "error" || rules["unused-id"] != "warning" {
+		t.Fatalf("expected duplicate-attribute errors and unused-id warnings, got: %#v", diags)
+	}
+}
+
+func TestCLILintExported(t *testing.T) {
+	schemaPath := filepath.Join("..", "..", "pkg", "hclschema", "testdata", "import_common.schema.hcl")
+	cmd := exec.Command("go", "run", "./main.go", "lint", "-exported", schemaPath)
+	out, err := cmd.Output()
+	if err != nil {
+		t.Fatalf("lint failed: %v; output: %s", err, string(out))
+	}
+
+	var diags []CLIOutDiagnostic
+	if err := json.Unmarshal(out, &diags); err != nil {
+		t.Fatalf("failed to parse CLI output as JSON: %v; output: %s", err, string(out))
+	}
+	if len(diags) > 0 {
+		t.Fatalf("expected the ids of an exported schema not to be reported, got: %#v", diags)
+	}
+}
+
+func TestCLIFmtCheck(t *testing.T) {
+	src, err := os.ReadFile(filepath.Join("..", "..", "pkg", "hclschema", "testdata", "format.schema.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	dir := t.TempDir()
+	schemaPath := filepath.Join(dir, "format.schema.hcl")
+	if err := os.WriteFile(schemaPath, src, 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	check := exec.Command("go", "run", "./main.go", "fmt", "-check", dir)
+	out, err := check.Output()
+	if exitErr, ok := err.(*exec.ExitError); !ok || exitErr.ExitCode() != 1 {
+		t.Fatalf("expected -check to exit with status 1, got %v; output: %s", err, string(out))
+	}
+	if strings.TrimSpace(string(out)) != schemaPath {
+		t.Fatalf("expected -check to list %s, got: %s", schemaPath, string(out))
+	}
+
+	if out, err := exec.Command("go", "run", "./main.go", "fmt", dir).CombinedOutput(); err != nil {
+		t.Fatalf("fmt failed: %v; output: %s", err, string(out))
+	}
+	if out, err := exec.Command("go", "run", "./main.go", "fmt", "-check", schemaPath).CombinedOutput(); err != nil {
+		t.Fatalf("expected formatted file to pass -check, got %v; output: %s", err, string(out))
+	}
+}
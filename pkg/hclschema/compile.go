@@ -0,0 +1,140 @@
+package hclschema
+
+import (
+	"context"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// Schema is a compiled schema: its refs, imports and extensions are resolved
+// and the hcl.BodySchema of each of its bodies is computed once, so it can
+// validate any number of files without parsing the schema again. A Schema is
+// immutable and safe for concurrent use.
+type Schema struct {
+	id  string
+	uri string
+	// linked is set for schemas that HCL files refer to with `__schema`,
+	// which their root body then accepts.
+	linked bool
+
+	body   *FullBodySchema
+	root   *hcl.BodySchema
+	bodies map[*FullBodySchema]*hcl.BodySchema
+
+	// warnings are the warnings found while loading the schema.
+	warnings hcl.Diagnostics
+}
+
+// Compile compiles the schema ref refers to, a path or an https:// URL, with
+// the imports it declares. The error is an hcl.Diagnostics if the schema is
+// invalid. The warnings of a valid schema, such as deprecations, are returned
+// by its Warnings method.
+func Compile(ref string) (*Schema, error) {
+	return CompileContext(context.Background(), ref)
+}
+
+// CompileContext is like Compile, but stops loading the schema and its
+// imports once ctx is done.
+func CompileContext(ctx context.Context, ref string) (*Schema, error) {
+	return NewValidator(ValidatorOptions{}).CompileContext(ctx, ref, "")
+}
+
+// newSchema compiles the root body fbs of the schema with the given id,
+// loaded from uri.
+func newSchema(id string, fbs *FullBodySchema, uri string, linked bool) *Schema {
+	s := &Schema{
+		id:     id,
+		uri:    uri,
+		linked: linked,
+		body:   fbs,
+		bodies: make(map[*FullBodySchema]*hcl.BodySchema),
+	}
+	s.compileBody(fbs)
+
+	s.root = &hcl.BodySchema{}
+	if fbs != nil {
+		s.root = fbs.AsBodySchema()
+	}
+	if linked {
+		s.root.Attributes = append(s.root.Attributes, hcl.AttributeSchema{Name: "__schema"})
+	}
+	return s
+}
+
+func (s *Schema) compileBody(fbs *FullBodySchema) {
+	if fbs == nil || s.bodies[fbs] != nil {
+		return
+	}
+	s.bodies[fbs] = fbs.AsBodySchema()
+	for i := range fbs.Blocks {
+		s.compileBody(fbs.Blocks[i].BodySchema)
+	}
+}
+
+// bodySchema returns the hcl.BodySchema of fbs, a body of s.
+func (s *Schema) bodySchema(fbs *FullBodySchema, root bool) *hcl.BodySchema {
+	if root {
+		return s.root
+	}
+	if bs, ok := s.bodies[fbs]; ok {
+		return bs
+	}
+	// Bodies built in Go after the schema was compiled.
+	if fbs == nil {
+		return &hcl.BodySchema{}
+	}
+	return fbs.AsBodySchema()
+}
+
+// ID returns the `__id` of the schema.
+func (s *Schema) ID() string {
+	return s.id
+}
+
+// Warnings returns the warnings found while loading and parsing the schema
+// and its imports, such as deprecations.
+func (s *Schema) Warnings() hcl.Diagnostics {
+	return s.warnings
+}
+
+// URI returns the canonical URI the schema was loaded from, which names it in
+// diagnostics.
+func (s *Schema) URI() string {
+	return s.uri
+}
+
+// Validate validates body, the root body of a file, against the schema.
+func (s *Schema) Validate(body hcl.Body) hcl.Diagnostics {
+	return s.ValidateContext(context.Background(), body)
+}
+
+// ValidateContext is like Validate, but stops once ctx is done, returning the
+// problems found so far followed by a "validation canceled" or "validation
+// deadline exceeded" error.
+func (s *Schema) ValidateContext(ctx context.Context, body hcl.Body) hcl.Diagnostics {
+	diags := s.validateBody(ctx, body, s.body, true)
+	if ctx.Err() != nil {
+		diags = append(diags, contextDiagnostic(ctx))
+	}
+	return diags
+}
+
+// ValidateFile validates the HCL file at filename against the schema,
+// whatever its `__schema` refers to. Files named `*.json` are parsed as JSON.
+func (s *Schema) ValidateFile(filename string) hcl.Diagnostics {
+	file, diags := parseConfigFile(filename)
+	if diags.HasErrors() {
+		return diags
+	}
+	return append(diags, s.Validate(file.Body)...)
+}
+
+// ValidateBytes is like ValidateFile, but validates the HCL content src,
+// named filename, instead of reading a file.
+func (s *Schema) ValidateBytes(src []byte, filename string) hcl.Diagnostics {
+	file, diags := parseConfig(src, filename)
+	if diags.HasErrors() {
+		return diags
+	}
+	return append(diags, s.Validate(file.Body)...)
+}
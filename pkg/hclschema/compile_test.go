@@ -0,0 +1,97 @@
+package hclschema
+
+import (
+	"context"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+)
+
+func TestCompile(t *testing.T) {
+	s, err := Compile(filepath.Join("testdata", "validation_rules.schema.hcl"))
+	if err != nil {
+		t.Fatalf("Compile returned an error: %v", err)
+	}
+	if s.URI() != filepath.Join("testdata", "validation_rules.schema.hcl") {
+		t.Fatalf("unexpected URI %s", s.URI())
+	}
+
+	if diags := s.ValidateFile(filepath.Join("testdata", "validation_rules.hcl")); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	// The compiled schema gives the same result as parsing it again.
+	violations := filepath.Join("testdata", "validation_rules_violations.hcl")
+	got := s.ValidateFile(violations)
+	want := ValidateFileWithSchema(filepath.Join("testdata", "validation_rules.schema.hcl"), violations)
+	if !got.HasErrors() || got.Error() != want.Error() {
+		t.Fatalf("expected %v, got %v", want, got)
+	}
+}
+
+func TestCompile_InvalidSchema(t *testing.T) {
+	_, err := Compile(filepath.Join("testdata", "ref_invalid.schema.hcl"))
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if _, ok := err.(hcl.Diagnostics); !ok {
+		t.Fatalf("expected hcl.Diagnostics, got %T", err)
+	}
+}
+
+func TestCompileKeepsWarnings(t *testing.T) {
+	// A dialect whose meta-schema deprecates `__id`, so that schema files
+	// using it are warned about while being compiled.
+	const url = "test://deprecated-id"
+	meta := *latestDialect.meta.body
+	meta.Attributes = slices.Clone(meta.Attributes)
+	for i := range meta.Attributes {
+		if meta.Attributes[i].Name == "__id" {
+			meta.Attributes[i].Deprecated = true
+		}
+	}
+	d := *latestDialect
+	d.url = url
+	d.meta = newSchema("", &meta, url, false)
+	dialects[url] = &d
+	t.Cleanup(func() { delete(dialects, url) })
+
+	v := NewValidator(ValidatorOptions{Resolver: MapResolver{
+		"schemas://simple": []byte("__schema = \"" + url + "\"\n__id = \"schemas://simple\"\n\nbody {\n    attribute \"name\" {}\n}\n"),
+	}})
+	s, err := v.Compile("schemas://simple", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if w := s.Warnings(); len(w) != 1 || w[0].Summary != "deprecated attribute" {
+		t.Fatalf("expected the deprecated __id to be warned about, got: %v", w)
+	}
+	// The warnings are reported by every validation using the cached schema.
+	for range 2 {
+		diags := v.ValidateBytes([]byte("__schema = \"schemas://simple\"\nname = \"api\"\n"), "service.hcl")
+		if len(diags) != 1 || diags[0].Summary != "deprecated attribute" {
+			t.Fatalf("expected the schema warning, got: %v", diags)
+		}
+	}
+}
+
+func TestSchemaValidateContextCanceled(t *testing.T) {
+	s, err := Compile(filepath.Join("testdata", "cardinality.schema.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	file, diags := hclparse.NewParser().ParseHCLFile(filepath.Join("testdata", "cardinality.hcl"))
+	if diags.HasErrors() {
+		t.Fatal(diags)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	diags = s.ValidateContext(ctx, file.Body)
+	// Blocks that weren't validated aren't reported missing.
+	if len(diags) != 1 || diags[0].Summary != "validation canceled" {
+		t.Fatalf("expected only a cancellation diagnostic, got: %v", diags)
+	}
+}
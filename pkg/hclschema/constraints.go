@@ -0,0 +1,233 @@
+package hclschema
+
+import (
+	"fmt"
+	"math/big"
+	"regexp"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ValueConstraints restricts the values accepted for an attribute. Unset
+// constraints are nil and are not checked.
+type ValueConstraints struct {
+	// AllowedValues lists every value the attribute may take.
+	AllowedValues []cty.Value
+	// Pattern must match string values.
+	Pattern *regexp.Regexp
+	// Min and Max bound number values, inclusive.
+	Min *big.Float
+	Max *big.Float
+	// MinLength and MaxLength bound the length of strings and collections,
+	// inclusive.
+	MinLength *int
+	MaxLength *int
+}
+
+func parseValueConstraints(attrs hcl.Attributes, ctx *hcl.EvalContext) (ValueConstraints, hcl.Diagnostics) {
+	var vc ValueConstraints
+	var diags hcl.Diagnostics
+
+	if a, ok := attrs["allowed_values"]; ok {
+		val, d := a.Expr.Value(ctx)
+		diags = append(diags, d...)
+		if !d.HasErrors() {
+			if val.IsNull() || !val.IsKnown() || !val.CanIterateElements() || val.Type().IsMapType() || val.Type().IsObjectType() {
+				diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid 'allowed_values'", Detail: "'allowed_values' must be a list of values", Subject: a.Expr.Range().Ptr()})
+			} else {
+				vc.AllowedValues = make([]cty.Value, 0, val.LengthInt())
+				for it := val.ElementIterator(); it.Next(); {
+					_, v := it.Element()
+					vc.AllowedValues = append(vc.AllowedValues, v)
+				}
+			}
+		}
+	}
+
+	if a, ok := attrs["pattern"]; ok {
+		val, d := a.Expr.Value(ctx)
+		diags = append(diags, d...)
+		if !d.HasErrors() {
+			if val.IsNull() || val.Type() != cty.String {
+				diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid 'pattern'", Detail: "'pattern' must be a string", Subject: a.Expr.Range().Ptr()})
+			} else if re, err := regexp.Compile(val.AsString()); err != nil {
+				diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid 'pattern'", Detail: err.Error(), Subject: a.Expr.Range().Ptr()})
+			} else {
+				vc.Pattern = re
+			}
+		}
+	}
+
+	var d hcl.Diagnostics
+	vc.Min, d = evalNumberAttr(attrs, "min", ctx)
+	diags = append(diags, d...)
+	vc.Max, d = evalNumberAttr(attrs, "max", ctx)
+	diags = append(diags, d...)
+	vc.MinLength, d = evalCountAttr(attrs, "min_length", ctx)
+	diags = append(diags, d...)
+	vc.MaxLength, d = evalCountAttr(attrs, "max_length", ctx)
+	diags = append(diags, d...)
+
+	return vc, diags
+}
+
+// evalBoolAttr evaluates the optional schema attribute `name` as a bool. It
+// returns false when the attribute is absent or invalid.
+func evalBoolAttr(attrs hcl.Attributes, name string, ctx *hcl.EvalContext) (bool, hcl.Diagnostics) {
+	a, ok := attrs[name]
+	if !ok {
+		return false, nil
+	}
+	val, diags := a.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return false, diags
+	}
+	if val.IsNull() || val.Type() != cty.Bool {
+		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: fmt.Sprintf("invalid '%s'", name), Detail: fmt.Sprintf("'%s' must be a bool", name), Subject: a.Expr.Range().Ptr()})
+		return false, diags
+	}
+	return val.True(), diags
+}
+
+// evalNumberAttr evaluates the optional schema attribute `name` as a number.
+// It returns nil when the attribute is absent or invalid.
+func evalNumberAttr(attrs hcl.Attributes, name string, ctx *hcl.EvalContext) (*big.Float, hcl.Diagnostics) {
+	a, ok := attrs[name]
+	if !ok {
+		return nil, nil
+	}
+	val, diags := a.Expr.Value(ctx)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if val.IsNull() || val.Type() != cty.Number {
+		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: fmt.Sprintf("invalid '%s'", name), Detail: fmt.Sprintf("'%s' must be a number", name), Subject: a.Expr.Range().Ptr()})
+		return nil, diags
+	}
+	return val.AsBigFloat(), diags
+}
+
+// evalCountAttr evaluates the optional schema attribute `name` as a
+// non-negative whole number. It returns nil when the attribute is absent or
+// invalid.
+func evalCountAttr(attrs hcl.Attributes, name string, ctx *hcl.EvalContext) (*int, hcl.Diagnostics) {
+	n, diags := evalNumberAttr(attrs, name, ctx)
+	if n == nil {
+		return nil, diags
+	}
+	i, acc := n.Int64()
+	if acc != big.Exact || i < 0 {
+		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: fmt.Sprintf("invalid '%s'", name), Detail: fmt.Sprintf("'%s' must be a non-negative whole number", name), Subject: attrs[name].Expr.Range().Ptr()})
+		return nil, diags
+	}
+	count := int(i)
+	return &count, diags
+}
+
+// check reports every constraint violated by val. Constraints that don't apply
+// to the value's type (e.g. a pattern on a number) are skipped, since the type
+// itself is checked separately.
+func (vc *ValueConstraints) check(name string, val cty.Value, rng hcl.Range) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if val.IsNull() || !val.IsWhollyKnown() {
+		return diags
+	}
+	ty := val.Type()
+
+	if len(vc.AllowedValues) > 0 {
+		allowed := false
+		for _, av := range vc.AllowedValues {
+			if av.Equals(val).True() {
+				allowed = true
+				break
+			}
+		}
+		if !allowed {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "value not allowed",
+				Detail:   fmt.Sprintf("'%s' must be one of %s", name, formatValues(vc.AllowedValues)),
+				Subject:  rng.Ptr(),
+			})
+		}
+	}
+
+	if vc.Pattern != nil && ty == cty.String && !vc.Pattern.MatchString(val.AsString()) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "value does not match pattern",
+			Detail:   fmt.Sprintf("'%s' must match the pattern %q", name, vc.Pattern.String()),
+			Subject:  rng.Ptr(),
+		})
+	}
+
+	if ty == cty.Number {
+		n := val.AsBigFloat()
+		if vc.Min != nil && n.Cmp(vc.Min) < 0 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "value too small",
+				Detail:   fmt.Sprintf("'%s' must be at least %s", name, vc.Min.Text('f', -1)),
+				Subject:  rng.Ptr(),
+			})
+		}
+		if vc.Max != nil && n.Cmp(vc.Max) > 0 {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "value too large",
+				Detail:   fmt.Sprintf("'%s' must be at most %s", name, vc.Max.Text('f', -1)),
+				Subject:  rng.Ptr(),
+			})
+		}
+	}
+
+	if vc.MinLength != nil || vc.MaxLength != nil {
+		length := -1
+		switch {
+		case ty == cty.String:
+			length = utf8.RuneCountInString(val.AsString())
+		case ty.IsCollectionType() || ty.IsTupleType():
+			length = val.LengthInt()
+		}
+		if length >= 0 && vc.MinLength != nil && length < *vc.MinLength {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "value too short",
+				Detail:   fmt.Sprintf("'%s' must have a length of at least %d, got %d", name, *vc.MinLength, length),
+				Subject:  rng.Ptr(),
+			})
+		}
+		if length >= 0 && vc.MaxLength != nil && length > *vc.MaxLength {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "value too long",
+				Detail:   fmt.Sprintf("'%s' must have a length of at most %d, got %d", name, *vc.MaxLength, length),
+				Subject:  rng.Ptr(),
+			})
+		}
+	}
+
+	return diags
+}
+
+func formatValues(vals []cty.Value) string {
+	parts := make([]string, 0, len(vals))
+	for _, v := range vals {
+		switch {
+		case v.IsNull():
+			parts = append(parts, "null")
+		case v.Type() == cty.String:
+			parts = append(parts, fmt.Sprintf("%q", v.AsString()))
+		case v.Type() == cty.Number:
+			parts = append(parts, v.AsBigFloat().Text('f', -1))
+		case v.Type() == cty.Bool:
+			parts = append(parts, fmt.Sprintf("%t", v.True()))
+		default:
+			parts = append(parts, v.GoString())
+		}
+	}
+	return "[" + strings.Join(parts, ", ") + "]"
+}
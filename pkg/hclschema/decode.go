@@ -0,0 +1,183 @@
+package hclschema
+
+import (
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+	"github.com/zclconf/go-cty/cty/convert"
+)
+
+// Decode decodes body into an object value shaped by schema.
+//
+// Every attribute of the schema becomes an attribute of the object. Omitted
+// attributes take their `default`, or null when they have none. Values are
+// converted to the attribute's type where possible. Undeclared attributes
+// accepted by an open body are included as well.
+//
+// Blocks are grouped by type. Depending on their nesting mode they decode to:
+//
+//   - "single": an object, or null when the block is absent
+//   - "map": an object keyed by the block labels, one level per label
+//   - "list", "set": a tuple of objects, in source order
+//
+// Block objects hold the decoded block body, plus each label under its name in
+// `label_names` unless an attribute of the body has the same name.
+//
+// Decode doesn't validate value constraints; use ValidateFileWithSchema for
+// that. Its diagnostics only cover problems that prevent decoding, such as
+// unsupported attributes or expressions that can't be evaluated.
+func Decode(schema *BlockHeaderAndBodySchema, body hcl.Body) (cty.Value, hcl.Diagnostics) {
+	if schema == nil || schema.BodySchema == nil {
+		return cty.EmptyObjectVal, nil
+	}
+	return decodeBody(body, schema.BodySchema, true)
+}
+
+func decodeBody(body hcl.Body, fbs *FullBodySchema, allowSchemaAttr bool) (cty.Value, hcl.Diagnostics) {
+	bs := fbs.AsBodySchema()
+	if allowSchemaAttr {
+		bs.Attributes = append(bs.Attributes, hcl.AttributeSchema{Name: "__schema"})
+	}
+	content, extra, diags := fbs.content(body, bs)
+
+	vals := make(map[string]cty.Value)
+	for i := range fbs.Attributes {
+		def := &fbs.Attributes[i]
+		vals[def.Name] = decodeAttribute(def, content.Attributes[def.Name], &diags)
+	}
+	for name, attr := range extra {
+		def := fbs.findAttributePattern(name)
+		if def == nil {
+			def = &FullAttributeSchema{}
+		}
+		vals[name] = decodeAttribute(def, attr, &diags)
+	}
+
+	types := make([]string, 0)
+	defs := make(map[string]*BlockHeaderAndBodySchema)
+	for i := range fbs.Blocks {
+		def := &fbs.Blocks[i]
+		if _, ok := defs[def.Type]; !ok {
+			types = append(types, def.Type)
+			defs[def.Type] = def
+		}
+	}
+	grouped := make(map[string][]*hcl.Block)
+	for _, blk := range content.Blocks {
+		grouped[blk.Type] = append(grouped[blk.Type], blk)
+	}
+
+	for _, typ := range types {
+		if _, ok := vals[typ]; ok {
+			// An attribute already claimed the name.
+			continue
+		}
+
+		objs := make([]cty.Value, 0, len(grouped[typ]))
+		blks := make([]*hcl.Block, 0, len(grouped[typ]))
+		for _, blk := range grouped[typ] {
+			def := fbs.findBlockDef(blk)
+			if def == nil {
+				continue
+			}
+			obj, d := decodeBlock(blk, def)
+			diags = append(diags, d...)
+			objs = append(objs, obj)
+			blks = append(blks, blk)
+		}
+
+		switch defs[typ].Nesting {
+		case NestingSingle:
+			if len(objs) == 0 {
+				vals[typ] = cty.NullVal(cty.DynamicPseudoType)
+			} else {
+				vals[typ] = objs[0]
+			}
+		case NestingMap:
+			vals[typ] = decodeBlockMap(blks, objs, 0)
+		default:
+			vals[typ] = cty.TupleVal(objs)
+		}
+	}
+
+	return cty.ObjectVal(vals), diags
+}
+
+// decodeAttribute returns the value of attr according to def, or the default
+// of def when attr is nil.
+func decodeAttribute(def *FullAttributeSchema, attr *hcl.Attribute, diags *hcl.Diagnostics) cty.Value {
+	ty := def.Type
+	if ty == cty.NilType {
+		ty = cty.DynamicPseudoType
+	}
+
+	var val cty.Value
+	switch {
+	case attr != nil:
+		v, d := attr.Expr.Value(nil)
+		*diags = append(*diags, d...)
+		if d.HasErrors() {
+			return cty.DynamicVal
+		}
+		val = v
+	case !def.Default.IsNull():
+		val = def.Default
+	default:
+		return cty.NullVal(ty)
+	}
+
+	if conv, err := convert.Convert(val, ty); err == nil {
+		return conv
+	}
+	return val
+}
+
+// decodeBlock decodes the body of blk, adding its labels.
+func decodeBlock(blk *hcl.Block, def *BlockHeaderAndBodySchema) (cty.Value, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	vals := make(map[string]cty.Value)
+	if def.BodySchema != nil {
+		obj, d := decodeBody(blk.Body, def.BodySchema, false)
+		diags = append(diags, d...)
+		vals = obj.AsValueMap()
+		if vals == nil {
+			vals = make(map[string]cty.Value)
+		}
+	}
+	for i, name := range def.LabelNames {
+		if _, ok := vals[name]; ok || i >= len(blk.Labels) {
+			continue
+		}
+		vals[name] = cty.StringVal(blk.Labels[i])
+	}
+	return cty.ObjectVal(vals), diags
+}
+
+// decodeBlockMap nests objs in one object level per label, starting at the
+// label at index level.
+func decodeBlockMap(blks []*hcl.Block, objs []cty.Value, level int) cty.Value {
+	keys := make([]string, 0)
+	groupedBlks := make(map[string][]*hcl.Block)
+	groupedObjs := make(map[string][]cty.Value)
+	for i, blk := range blks {
+		if level >= len(blk.Labels) {
+			continue
+		}
+		key := blk.Labels[level]
+		if _, ok := groupedBlks[key]; !ok {
+			keys = append(keys, key)
+		}
+		groupedBlks[key] = append(groupedBlks[key], blk)
+		groupedObjs[key] = append(groupedObjs[key], objs[i])
+	}
+
+	vals := make(map[string]cty.Value, len(keys))
+	for _, key := range keys {
+		if level == len(groupedBlks[key][0].Labels)-1 {
+			// Duplicate keys are reported by validation; the first one wins.
+			vals[key] = groupedObjs[key][0]
+			continue
+		}
+		vals[key] = decodeBlockMap(groupedBlks[key], groupedObjs[key], level+1)
+	}
+	return cty.ObjectVal(vals)
+}
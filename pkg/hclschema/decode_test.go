@@ -0,0 +1,67 @@
+package hclschema
+
+import (
+	"path/filepath"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+func TestDecodeAppliesDefaults(t *testing.T) {
+	schema, diags := ParseSchemaFile(filepath.Join("testdata", "decode.schema.hcl"))
+	if diags.HasErrors() {
+		t.Fatalf("schema parse diagnostics had errors: %v", diags)
+	}
+
+	parser := hclparse.NewParser()
+	file, diags := parser.ParseHCLFile(filepath.Join("testdata", "decode.hcl"))
+	if diags.HasErrors() {
+		t.Fatalf("failed parsing hcl file: %v", diags)
+	}
+
+	val, diags := Decode(schema, file.Body)
+	if diags.HasErrors() {
+		t.Fatalf("Decode returned errors: %v", diags)
+	}
+
+	expected := cty.ObjectVal(map[string]cty.Value{
+		"name":  cty.StringVal("api"),
+		"port":  cty.NumberIntVal(8080),
+		"hosts": cty.ListVal([]cty.Value{cty.StringVal("a"), cty.StringVal("b")}),
+		"listener": cty.TupleVal([]cty.Value{
+			cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("http"), "tls": cty.False}),
+			cty.ObjectVal(map[string]cty.Value{"name": cty.StringVal("https"), "tls": cty.True}),
+		}),
+		"settings": cty.NullVal(cty.DynamicPseudoType),
+		"route": cty.ObjectVal(map[string]cty.Value{
+			"/": cty.ObjectVal(map[string]cty.Value{"path": cty.StringVal("/"), "target": cty.StringVal("home")}),
+		}),
+	})
+	if !val.RawEquals(expected) {
+		t.Fatalf("unexpected decoded value:\ngot:  %#v\nwant: %#v", val, expected)
+	}
+}
+
+func TestDecodeSingleBlockDefaults(t *testing.T) {
+	schema, diags := ParseSchemaFile(filepath.Join("testdata", "decode.schema.hcl"))
+	if diags.HasErrors() {
+		t.Fatalf("schema parse diagnostics had errors: %v", diags)
+	}
+
+	parser := hclparse.NewParser()
+	file, diags := parser.ParseHCL([]byte("name = \"api\"\nsettings {}\n"), "inline.hcl")
+	if diags.HasErrors() {
+		t.Fatalf("failed parsing hcl: %v", diags)
+	}
+
+	val, diags := Decode(schema, file.Body)
+	if diags.HasErrors() {
+		t.Fatalf("Decode returned errors: %v", diags)
+	}
+
+	settings := val.GetAttr("settings")
+	if settings.IsNull() || !settings.GetAttr("debug").RawEquals(cty.False) {
+		t.Fatalf("expected settings.debug to default to false, got: %#v", settings)
+	}
+}
@@ -0,0 +1,45 @@
+package hclschema
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// parseDefinitions parses the `definitions` blocks of a schema root body into
+// the placeholders collectRefs declared for them. A `body "name"` definition
+// can be referred to from a block_header as `ref = definitions.body.name`, and
+// an `attribute "name"` definition from an attribute as
+// `ref = definitions.attribute.name`.
+func parseDefinitions(body hcl.Body, refs *schemaRefs) hcl.Diagnostics {
+	content, _, diags := body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "definitions"}},
+	})
+
+	for _, block := range content.Blocks {
+		defsContent, d := block.Body.Content(refs.dialect.definitions)
+		diags = append(diags, d...)
+		for _, def := range defsContent.Blocks {
+			key := fmt.Sprintf("definitions.%s.%s", def.Type, def.Labels[0])
+			switch def.Type {
+			case "body":
+				if owner, ok := refs.owners[key]; !ok || owner != def.DefRange {
+					// A duplicate, reported by collectRefs.
+					continue
+				}
+				fbs, d := parseBody(def.Body, refs.dialect.body, refs)
+				diags = append(diags, d...)
+				if fbs != nil {
+					*refs.bodies[key] = *fbs
+					refs.moveRuleChecks(fbs, refs.bodies[key])
+				}
+			case "attribute":
+				// Parses the definition unless a ref to it already did.
+				_, _, d := refs.attribute(key, def.DefRange)
+				diags = append(diags, d...)
+			}
+		}
+	}
+
+	return diags
+}
@@ -0,0 +1,153 @@
+package hclschema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	godschema "github.com/avestura/hcl-schema/pkg/hclschema/god_schema"
+	metaschema "github.com/avestura/hcl-schema/schema"
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Draft202510 is the URL of the draft 2025-10 meta-schema, which schema files
+// set `__schema` to.
+const Draft202510 = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+
+// dialect is a version of the schema language. It holds the keywords each
+// construct of a schema file accepts, and is selected by the meta-schema URL
+// the file sets `__schema` to.
+type dialect struct {
+	url string
+	// meta is the compiled meta-schema, which schema files of the dialect
+	// are validated against before being parsed.
+	meta *Schema
+
+	root        *hcl.BodySchema
+	body        *hcl.BodySchema
+	blockHeader *hcl.BodySchema
+	attribute   *hcl.BodySchema
+	label       *hcl.BodySchema
+	matchLabel  *hcl.BodySchema
+	validation  *hcl.BodySchema
+	imports     *hcl.BodySchema
+	definitions *hcl.BodySchema
+}
+
+// bootstrapDialect is the dialect meta-schemas are parsed with. It only knows
+// the keywords meta-schemas use themselves.
+var bootstrapDialect = &dialect{
+	root:        godschema.GetRootSchema(),
+	body:        godschema.GetBodySchema(),
+	blockHeader: godschema.GetBlockHeaderSchema(),
+	attribute:   godschema.GetAttributeSchema(),
+	label:       &hcl.BodySchema{},
+	matchLabel:  &hcl.BodySchema{},
+	validation:  &hcl.BodySchema{},
+	imports:     &hcl.BodySchema{},
+	definitions: &hcl.BodySchema{},
+}
+
+// dialects holds the known dialects, keyed by meta-schema URL.
+var dialects = make(map[string]*dialect)
+
+// latestDialect is used for schema files that don't declare `__schema`, so
+// that the missing attribute is reported like any other.
+var latestDialect *dialect
+
+func init() {
+	dialects[Draft202510] = mustLoadDialect(Draft202510, metaschema.Draft202510)
+	latestDialect = dialects[Draft202510]
+}
+
+// loadDialect parses the meta-schema src, published at url, and derives the
+// keywords of each construct of the dialect from the blocks it describes.
+func loadDialect(url string, src []byte) (*dialect, error) {
+	file, diags := hclparse.NewParser().ParseHCL(src, url)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	meta, _, diags := parseSchema(file, url, nil, bootstrapDialect, defaultSchemaLoader())
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	var missing []string
+	find := func(fbs *FullBodySchema, typ string) *FullBodySchema {
+		if fbs != nil {
+			for i := range fbs.Blocks {
+				if fbs.Blocks[i].Type == typ && fbs.Blocks[i].BodySchema != nil {
+					return fbs.Blocks[i].BodySchema
+				}
+			}
+		}
+		missing = append(missing, typ)
+		return &FullBodySchema{}
+	}
+	body := find(meta, "body")
+	blockHeader := find(body, "block_header")
+	attribute := find(body, "attribute")
+	d := &dialect{
+		url:         url,
+		meta:        newSchema("", meta, url, false),
+		root:        meta.AsBodySchema(),
+		body:        body.AsBodySchema(),
+		blockHeader: blockHeader.AsBodySchema(),
+		attribute:   attribute.AsBodySchema(),
+		label:       find(blockHeader, "label").AsBodySchema(),
+		matchLabel:  find(blockHeader, "match_label").AsBodySchema(),
+		validation:  find(attribute, "validation").AsBodySchema(),
+		imports:     find(meta, "import").AsBodySchema(),
+		definitions: find(meta, "definitions").AsBodySchema(),
+	}
+	if len(missing) > 0 {
+		return nil, fmt.Errorf("meta-schema %s doesn't describe the %s blocks", url, quoteNames(missing))
+	}
+	return d, nil
+}
+
+func mustLoadDialect(url string, src []byte) *dialect {
+	d, err := loadDialect(url, src)
+	if err != nil {
+		panic(fmt.Sprintf("hclschema: loading dialect %s: %s", url, err))
+	}
+	return d
+}
+
+// detectDialect returns the dialect selected by the `__schema` attribute of a
+// schema root body.
+func detectDialect(body hcl.Body) (*dialect, hcl.Diagnostics) {
+	content, _, _ := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "__schema"}},
+	})
+	a, ok := content.Attributes["__schema"]
+	if !ok {
+		return latestDialect, nil
+	}
+
+	val, diags := a.Expr.Value(schemaEvalContext)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if val.IsNull() || val.Type() != cty.String {
+		return nil, append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid '__schema'", Detail: "'__schema' must be a string", Subject: a.Expr.Range().Ptr()})
+	}
+
+	d, ok := dialects[val.AsString()]
+	if !ok {
+		known := make([]string, 0, len(dialects))
+		for url := range dialects {
+			known = append(known, url)
+		}
+		slices.Sort(known)
+		return nil, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "unknown schema dialect",
+			Detail:   fmt.Sprintf("'%s' is not a known meta-schema; supported dialects are:\n%s", val.AsString(), strings.Join(known, "\n")),
+			Subject:  a.Expr.Range().Ptr(),
+		})
+	}
+	return d, diags
+}
@@ -0,0 +1,320 @@
+package hclschema
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// bodyExtension is the body of a block_header that `extends` another body.
+// Since the extended body may not be parsed yet, the two are merged by
+// applyExtensions once the whole schema is.
+type bodyExtension struct {
+	// target receives the merged body.
+	target *FullBodySchema
+	// child is the body declared by the block_header, if any, and childBody
+	// its source.
+	child     *FullBodySchema
+	childBody hcl.Body
+
+	parentKey string
+	extends   *hcl.Attribute
+	remove    []string
+	removeRng hcl.Range
+}
+
+// parseExtension records the extension declared by a block_header with an
+// `extends` attribute. nested is the body the block_header declares and
+// placeholder the body its id stands for, if any. It returns the body the
+// block_header ends up with.
+func parseExtension(attrs hcl.Attributes, nested, placeholder *FullBodySchema, bodyBlock *hcl.Block, refs *schemaRefs) (*FullBodySchema, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	a := attrs["extends"]
+
+	ext := &bodyExtension{target: placeholder, extends: a}
+	if ext.target == nil {
+		ext.target = &FullBodySchema{}
+	}
+	if nested != nil {
+		child := *nested
+		ext.child = &child
+	}
+	if bodyBlock != nil {
+		ext.childBody = bodyBlock.Body
+	}
+
+	key, d := parseRefKey(a)
+	diags = append(diags, d...)
+	if key == "" {
+		return nested, diags
+	}
+	ext.parentKey = key
+
+	if ra, ok := attrs["remove"]; ok {
+		val, d := ra.Expr.Value(schemaEvalContext)
+		diags = append(diags, d...)
+		if !d.HasErrors() {
+			names, ok := stringList(val)
+			if !ok {
+				diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid 'remove'", Detail: "'remove' must be a list of attribute and block names", Subject: ra.Expr.Range().Ptr()})
+			}
+			ext.remove = names
+			ext.removeRng = ra.Expr.Range()
+		}
+	}
+
+	if nested != nil {
+		refs.moveRuleChecks(nested, ext.target)
+	}
+	refs.extensions = append(refs.extensions, ext)
+	return ext.target, diags
+}
+
+// applyExtensions merges every recorded extension with the body it extends,
+// extending that body first if it's an extension itself.
+func applyExtensions(refs *schemaRefs) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	byTarget := make(map[*FullBodySchema]*bodyExtension, len(refs.extensions))
+	for _, ext := range refs.extensions {
+		byTarget[ext.target] = ext
+	}
+	done := make(map[*bodyExtension]bool)
+	active := make(map[*bodyExtension]bool)
+
+	var apply func(ext *bodyExtension)
+	apply = func(ext *bodyExtension) {
+		if done[ext] {
+			return
+		}
+		if active[ext] {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "extends cycle",
+				Detail:   fmt.Sprintf("extending '%s' leads back to this body", ext.parentKey),
+				Subject:  ext.extends.Range.Ptr(),
+			})
+			return
+		}
+		active[ext] = true
+		defer func() {
+			delete(active, ext)
+			done[ext] = true
+		}()
+
+		parent, ok := refs.bodies[ext.parentKey]
+		if !ok {
+			diags = append(diags, unresolvedRefDiagnostic(ext.parentKey, ext.extends))
+			return
+		}
+		if pext, ok := byTarget[parent]; ok {
+			apply(pext)
+		}
+		if parent == nil {
+			parent = &FullBodySchema{}
+		}
+
+		merged, d := extendBody(parent, ext, refs.dialect)
+		diags = append(diags, d...)
+		*ext.target = *merged
+	}
+
+	for _, ext := range refs.extensions {
+		apply(ext)
+	}
+	refs.extensions = nil
+	return diags
+}
+
+// extendBody returns a copy of parent without the members ext removes, and
+// with the members of its child body added. Child attributes override the
+// properties they declare of parent attributes with the same name. Child
+// block_headers replace the parent's definitions of the same block type.
+// dialect is the dialect of the child body's schema file.
+func extendBody(parent *FullBodySchema, ext *bodyExtension, dialect *dialect) (*FullBodySchema, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	res := &FullBodySchema{
+		Metadata:             parent.Metadata,
+		Attributes:           slices.Clone(parent.Attributes),
+		Blocks:               slices.Clone(parent.Blocks),
+		AttributePatterns:    slices.Clone(parent.AttributePatterns),
+		AdditionalAttributes: parent.AdditionalAttributes,
+		AdditionalBlocks:     parent.AdditionalBlocks,
+	}
+	res.AttributeRules.append(parent.AttributeRules)
+
+	for _, name := range ext.remove {
+		found := false
+		res.Attributes = slices.DeleteFunc(res.Attributes, func(a FullAttributeSchema) bool {
+			found = found || a.Name == name
+			return a.Name == name
+		})
+		res.Blocks = slices.DeleteFunc(res.Blocks, func(b BlockHeaderAndBodySchema) bool {
+			found = found || b.Type == name
+			return b.Type == name
+		})
+		if !found {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "invalid 'remove'",
+				Detail:   fmt.Sprintf("'%s' is not declared by '%s'", name, ext.parentKey),
+				Subject:  ext.removeRng.Ptr(),
+			})
+		}
+	}
+	if len(ext.remove) > 0 {
+		res.AttributeRules = res.AttributeRules.without(ext.remove)
+		for i := range res.Attributes {
+			res.Attributes[i].AttributeRules = res.Attributes[i].AttributeRules.without(ext.remove)
+		}
+	}
+
+	child := ext.child
+	if child == nil {
+		return res, diags
+	}
+
+	var declared hcl.Attributes
+	declaredAttrs := make(map[string]hcl.Attributes)
+	if ext.childBody != nil {
+		content, _, _ := ext.childBody.PartialContent(dialect.body)
+		declared = content.Attributes
+		for _, blk := range content.Blocks {
+			if blk.Type == "attribute" {
+				attrContent, _, _ := blk.Body.PartialContent(dialect.attribute)
+				declaredAttrs[blk.Labels[0]] = attrContent.Attributes
+			}
+		}
+	}
+
+	res.Metadata = overrideMetadata(res.Metadata, child.Metadata, declared)
+	if _, ok := declared["additional_attributes"]; ok {
+		res.AdditionalAttributes = child.AdditionalAttributes
+	}
+	if _, ok := declared["additional_blocks"]; ok {
+		res.AdditionalBlocks = child.AdditionalBlocks
+	}
+	res.AttributeRules.append(child.AttributeRules)
+
+	for _, attr := range child.Attributes {
+		i := slices.IndexFunc(res.Attributes, func(a FullAttributeSchema) bool { return a.Name == attr.Name })
+		if i < 0 {
+			res.Attributes = append(res.Attributes, attr)
+			continue
+		}
+		merged, d := overrideAttribute(res.Attributes[i], attr, declaredAttrs[attr.Name])
+		diags = append(diags, d...)
+		res.Attributes[i] = merged
+	}
+
+	for _, pattern := range child.AttributePatterns {
+		i := slices.IndexFunc(res.AttributePatterns, func(p AttributePattern) bool { return p.Pattern.String() == pattern.Pattern.String() })
+		if i < 0 {
+			res.AttributePatterns = append(res.AttributePatterns, pattern)
+		} else {
+			res.AttributePatterns[i] = pattern
+		}
+	}
+
+	res.Blocks = slices.DeleteFunc(res.Blocks, func(b BlockHeaderAndBodySchema) bool {
+		return slices.ContainsFunc(child.Blocks, func(c BlockHeaderAndBodySchema) bool { return c.Type == b.Type })
+	})
+	res.Blocks = append(res.Blocks, child.Blocks...)
+
+	return res, diags
+}
+
+// overrideAttribute returns base with the properties in declared taken from
+// child. Validations of both apply. An attribute with a `ref` replaces base
+// entirely.
+func overrideAttribute(base, child FullAttributeSchema, declared hcl.Attributes) (FullAttributeSchema, hcl.Diagnostics) {
+	if _, ok := declared["ref"]; ok || declared == nil {
+		return child, nil
+	}
+	var diags hcl.Diagnostics
+	res := base
+	has := func(name string) bool {
+		_, ok := declared[name]
+		return ok
+	}
+
+	if has("required") {
+		res.Required = child.Required
+	}
+	if has("type") {
+		res.Type = child.Type
+	}
+	if has("default") {
+		res.Default = child.Default
+	}
+	if has("allowed_values") {
+		res.AllowedValues = child.AllowedValues
+	}
+	if has("pattern") {
+		res.Pattern = child.Pattern
+	}
+	if has("min") {
+		res.Min = child.Min
+	}
+	if has("max") {
+		res.Max = child.Max
+	}
+	if has("min_length") {
+		res.MinLength = child.MinLength
+	}
+	if has("max_length") {
+		res.MaxLength = child.MaxLength
+	}
+	if has("one_of") {
+		res.OneOf = child.OneOf
+	}
+	if has("exactly_one_of") {
+		res.ExactlyOneOf = child.ExactlyOneOf
+	}
+	if has("conflicts_with") {
+		res.ConflictsWith = child.ConflictsWith
+	}
+	if has("required_with") {
+		res.RequiredWith = child.RequiredWith
+	}
+	res.Metadata = overrideMetadata(base.Metadata, child.Metadata, declared)
+	res.Validations = append(slices.Clip(base.Validations), child.Validations...)
+
+	// The default and the type may now come from different attributes.
+	if (has("type") || has("default")) && res.Type != cty.NilType && !res.Default.IsNull() && !valueConformsToType(res.Default, res.Type) {
+		rng := declared["default"]
+		if rng == nil {
+			rng = declared["type"]
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "invalid 'default'",
+			Detail:   fmt.Sprintf("default of attribute '%s' must be %s, got %s", res.Name, typeexpr.TypeString(res.Type), res.Default.Type().FriendlyName()),
+			Subject:  rng.Expr.Range().Ptr(),
+		})
+	}
+
+	return res, diags
+}
+
+// overrideMetadata returns base with the properties in declared taken from
+// child.
+func overrideMetadata(base, child Metadata, declared hcl.Attributes) Metadata {
+	res := base
+	if _, ok := declared["description"]; ok {
+		res.Description = child.Description
+	}
+	if _, ok := declared["examples"]; ok {
+		res.Examples = child.Examples
+	}
+	_, hasMessage := declared["deprecation_message"]
+	if _, ok := declared["deprecated"]; ok || hasMessage {
+		res.Deprecated = child.Deprecated
+	}
+	if hasMessage {
+		res.DeprecationMessage = child.DeprecationMessage
+	}
+	return res
+}
@@ -0,0 +1,262 @@
+package hclschema
+
+import (
+	"cmp"
+	"slices"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// FormatOptions enables the optional rewrites of FormatSchema.
+type FormatOptions struct {
+	// SortMembers sorts the attribute and block_header blocks of every body,
+	// and the definitions, by name. Blocks with the same name keep their
+	// order, so findBlockDef picks the same definitions, and so do
+	// attribute_pattern blocks, since the first one matching a name applies.
+	SortMembers bool
+	// StripRedundantRequired removes `required = false` from attributes,
+	// since attributes are optional by default.
+	StripRedundantRequired bool
+	// NormalizeLabelNames rewrites `label_names` as a single line list.
+	NormalizeLabelNames bool
+}
+
+// FormatSchema returns the schema file src, named filename, in canonical
+// layout: the properties of each construct come first, in the order its
+// meta-schema declares them, followed by its blocks, separated by blank
+// lines. Comments move along with the property or block they precede.
+func FormatSchema(src []byte, filename string, opts FormatOptions) ([]byte, hcl.Diagnostics) {
+	syntaxFile, diags := hclsyntax.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	d, diags := detectDialect(syntaxFile.Body)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	file, diags := hclwrite.ParseConfig(src, filename, hcl.InitialPos)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+
+	f := &formatter{dialect: d, opts: opts}
+	f.formatRoot(file.Body())
+	return hclwrite.Format(file.Bytes()), nil
+}
+
+type formatter struct {
+	dialect *dialect
+	opts    FormatOptions
+}
+
+func (f *formatter) formatRoot(body *hclwrite.Body) {
+	for _, block := range body.Blocks() {
+		switch block.Type() {
+		case "import":
+			reorderBody(block.Body(), f.dialect.imports, false)
+		case "body":
+			f.formatBody(block.Body())
+		case "definitions":
+			for _, def := range block.Body().Blocks() {
+				switch def.Type() {
+				case "body":
+					f.formatBody(def.Body())
+				case "attribute":
+					f.formatAttribute(def.Body())
+				}
+			}
+			reorderBody(block.Body(), f.dialect.definitions, f.opts.SortMembers)
+		}
+	}
+	reorderBody(body, f.dialect.root, false)
+}
+
+func (f *formatter) formatBody(body *hclwrite.Body) {
+	for _, block := range body.Blocks() {
+		switch block.Type() {
+		case "attribute", "attribute_pattern":
+			f.formatAttribute(block.Body())
+		case "block_header":
+			f.formatBlockHeader(block.Body())
+		}
+	}
+	reorderBody(body, f.dialect.body, f.opts.SortMembers)
+}
+
+func (f *formatter) formatBlockHeader(body *hclwrite.Body) {
+	if a := body.GetAttribute("label_names"); a != nil && f.opts.NormalizeLabelNames {
+		val, _ := attributeValue(a)
+		if names, ok := stringList(val); ok {
+			body.SetAttributeValue("label_names", stringTuple(names))
+		}
+	}
+
+	for _, block := range body.Blocks() {
+		switch block.Type() {
+		case "body":
+			f.formatBody(block.Body())
+		case "label":
+			reorderBody(block.Body(), f.dialect.label, false)
+		case "match_label":
+			reorderBody(block.Body(), f.dialect.matchLabel, false)
+		case "validation":
+			reorderBody(block.Body(), f.dialect.validation, false)
+		}
+	}
+	reorderBody(body, f.dialect.blockHeader, false)
+}
+
+func (f *formatter) formatAttribute(body *hclwrite.Body) {
+	if a := body.GetAttribute("required"); a != nil && f.opts.StripRedundantRequired {
+		if val, ok := attributeValue(a); ok && val.Type() == cty.Bool && val.False() {
+			body.RemoveAttribute("required")
+		}
+	}
+
+	for _, block := range body.Blocks() {
+		if block.Type() == "validation" {
+			reorderBody(block.Body(), f.dialect.validation, false)
+		}
+	}
+	reorderBody(body, f.dialect.attribute, false)
+}
+
+// attributeValue evaluates the expression of a, reporting false if it isn't
+// a known constant.
+func attributeValue(a *hclwrite.Attribute) (cty.Value, bool) {
+	expr, diags := hclsyntax.ParseExpression(a.Expr().BuildTokens(nil).Bytes(), "", hcl.InitialPos)
+	if diags.HasErrors() {
+		return cty.NilVal, false
+	}
+	val, diags := expr.Value(schemaEvalContext)
+	if diags.HasErrors() || !val.IsWhollyKnown() || val.IsNull() {
+		return cty.NilVal, false
+	}
+	return val, true
+}
+
+// formatItem is an attribute or block of a body, along with the comments
+// preceding it that aren't part of its tokens.
+type formatItem struct {
+	comments hclwrite.Tokens
+	tokens   hclwrite.Tokens
+	block    *hclwrite.Block
+	// rank orders attributes by the position of their name in the schema of
+	// the body. Unknown attributes and blocks keep their relative order.
+	rank int
+}
+
+// reorderBody rewrites body with its attributes first, in the order schema
+// declares them, followed by its blocks, sorted by type and first label if
+// sortBlocks is set. Empty bodies are collapsed, and bodies with a single
+// item, such as single line blocks, are left as they are.
+func reorderBody(body *hclwrite.Body, schema *hcl.BodySchema, sortBlocks bool) {
+	attrs := body.Attributes()
+	blocks := body.Blocks()
+	if len(attrs)+len(blocks) == 0 && !slices.ContainsFunc(body.BuildTokens(nil), func(t *hclwrite.Token) bool { return t.Type == hclsyntax.TokenComment }) {
+		body.Clear()
+		return
+	}
+	if len(attrs)+len(blocks) < 2 {
+		return
+	}
+
+	byFirstToken := make(map[*hclwrite.Token]*formatItem, len(attrs)+len(blocks))
+	for name, a := range attrs {
+		rank := slices.IndexFunc(schema.Attributes, func(s hcl.AttributeSchema) bool { return s.Name == name })
+		if rank < 0 {
+			rank = len(schema.Attributes)
+		}
+		tokens := a.BuildTokens(nil)
+		byFirstToken[tokens[0]] = &formatItem{tokens: tokens, rank: rank}
+	}
+	for _, b := range blocks {
+		tokens := b.BuildTokens(nil)
+		byFirstToken[tokens[0]] = &formatItem{tokens: tokens, block: b}
+	}
+
+	var items []*formatItem
+	var comments hclwrite.Tokens
+	all := body.BuildTokens(nil)
+	for i := 0; i < len(all); i++ {
+		item, ok := byFirstToken[all[i]]
+		if !ok {
+			if all[i].Type == hclsyntax.TokenComment {
+				comments = append(comments, all[i])
+			}
+			continue
+		}
+		item.comments = comments
+		comments = nil
+		items = append(items, item)
+		i += len(item.tokens) - 1
+	}
+
+	var attrItems, blockItems []*formatItem
+	for _, item := range items {
+		if item.block == nil {
+			attrItems = append(attrItems, item)
+		} else {
+			blockItems = append(blockItems, item)
+		}
+	}
+	slices.SortStableFunc(attrItems, func(a, b *formatItem) int { return cmp.Compare(a.rank, b.rank) })
+	if sortBlocks {
+		slices.SortStableFunc(blockItems, func(a, b *formatItem) int {
+			if c := cmp.Compare(a.block.Type(), b.block.Type()); c != 0 || a.block.Type() == "attribute_pattern" {
+				// The first attribute_pattern matching a name applies, so
+				// their order is kept.
+				return c
+			}
+			return cmp.Compare(firstLabel(a.block), firstLabel(b.block))
+		})
+	}
+
+	// The tokens of a block body start with the newline after its opening
+	// brace, unlike those of a file.
+	body.Clear()
+	if all[0].Type == hclsyntax.TokenNewline {
+		body.AppendNewline()
+	}
+	for _, item := range attrItems {
+		appendItem(body, item)
+	}
+	for i, item := range blockItems {
+		if i > 0 || len(attrItems) > 0 {
+			body.AppendNewline()
+		}
+		appendItem(body, item)
+	}
+	if len(comments) > 0 {
+		body.AppendNewline()
+		appendComments(body, comments)
+	}
+}
+
+func appendItem(body *hclwrite.Body, item *formatItem) {
+	appendComments(body, item.comments)
+	body.AppendUnstructuredTokens(item.tokens)
+	if last := item.tokens[len(item.tokens)-1]; last.Type != hclsyntax.TokenNewline && last.Type != hclsyntax.TokenComment {
+		body.AppendNewline()
+	}
+}
+
+// appendComments appends comments to body, each on its own line.
+func appendComments(body *hclwrite.Body, comments hclwrite.Tokens) {
+	for _, c := range comments {
+		body.AppendUnstructuredTokens(hclwrite.Tokens{c})
+		if b := c.Bytes; len(b) == 0 || b[len(b)-1] != '\n' {
+			body.AppendNewline()
+		}
+	}
+}
+
+func firstLabel(b *hclwrite.Block) string {
+	if labels := b.Labels(); len(labels) > 0 {
+		return labels[0]
+	}
+	return ""
+}
@@ -0,0 +1,164 @@
+package hclschema
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+const formattedSchema = `__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://format"
+
+body {
+  additional_blocks = false
+
+  block_header "zone" {
+    // The zone label.
+    label_names = [
+      "name",
+    ]
+    min_items = 1
+
+    body {
+      attribute "name" {}
+    }
+  }
+
+  attribute "port" {
+    required = false
+    type     = number # inclusive
+    min      = 1
+    max      = 65535
+  }
+
+  // Any name is accepted.
+  attribute "name" { required = true }
+
+  attribute "x" {}
+
+  attribute "a" {
+    required    = true
+    description = "first"
+  }
+}
+`
+
+const formattedSchemaAllOptions = `__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://format"
+
+body {
+  additional_blocks = false
+
+  attribute "a" {
+    required    = true
+    description = "first"
+  }
+
+  // Any name is accepted.
+  attribute "name" { required = true }
+
+  attribute "port" {
+    type = number # inclusive
+    min  = 1
+    max  = 65535
+  }
+
+  attribute "x" {}
+
+  block_header "zone" {
+    // The zone label.
+    label_names = ["name"]
+    min_items   = 1
+
+    body {
+      attribute "name" {}
+    }
+  }
+}
+`
+
+func TestFormatSchema(t *testing.T) {
+	path := filepath.Join("testdata", "format.schema.hcl")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	cases := []struct {
+		name string
+		opts FormatOptions
+		want string
+	}{
+		{"default", FormatOptions{}, formattedSchema},
+		{"all options", FormatOptions{SortMembers: true, StripRedundantRequired: true, NormalizeLabelNames: true}, formattedSchemaAllOptions},
+	}
+	for _, c := range cases {
+		t.Run(c.name, func(t *testing.T) {
+			out, diags := FormatSchema(src, path, c.opts)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors: %v", diags)
+			}
+			if string(out) != c.want {
+				t.Fatalf("unexpected output:\n%s", out)
+			}
+
+			again, diags := FormatSchema(out, path, c.opts)
+			if diags.HasErrors() {
+				t.Fatalf("unexpected errors formatting the output: %v", diags)
+			}
+			if string(again) != string(out) {
+				t.Fatalf("formatting is not idempotent:\n%s", again)
+			}
+
+			formatted := filepath.Join(t.TempDir(), "format.schema.hcl")
+			if err := os.WriteFile(formatted, out, 0o644); err != nil {
+				t.Fatal(err)
+			}
+			if _, diags := ParseSchemaFile(formatted); diags.HasErrors() {
+				t.Fatalf("formatted schema doesn't parse: %v", diags)
+			}
+		})
+	}
+}
+
+func TestFormatSchema_SortMembersKeepsPatternOrder(t *testing.T) {
+	src := `__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+
+body {
+  attribute_pattern "^x_" { type = number }
+  attribute_pattern ".*" { type = string }
+  attribute "b" {}
+  attribute "a" {}
+}
+`
+	want := `__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+
+body {
+  attribute "a" {}
+
+  attribute "b" {}
+
+  attribute_pattern "^x_" { type = number }
+
+  attribute_pattern ".*" { type = string }
+}
+`
+	out, diags := FormatSchema([]byte(src), "patterns.schema.hcl", FormatOptions{SortMembers: true})
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	if string(out) != want {
+		t.Fatalf("unexpected output:\n%s", out)
+	}
+}
+
+func TestFormatSchema_UnknownDialect(t *testing.T) {
+	path := filepath.Join("testdata", "unknown_dialect.schema.hcl")
+	src, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, diags := FormatSchema(src, path, FormatOptions{}); !diags.HasErrors() {
+		t.Fatalf("expected an error formatting a schema of an unknown dialect")
+	}
+}
@@ -1,3 +1,7 @@
+// Package godschema holds the minimal schema of schema files that the root
+// schemas in the schema directory are parsed with. The keywords of each
+// dialect are derived from its root schema, so this only needs to cover the
+// keywords root schemas use themselves.
 package godschema
 
 import "github.com/hashicorp/hcl/v2"
@@ -0,0 +1,118 @@
+package hclschema
+
+import (
+	"fmt"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// parseImports resolves the `import` blocks of a schema root body, relative to
+// location, and adds the ids and definitions of every imported schema to refs
+// under the import name, so that `import "common"` makes the id
+// `block_header.tls` of the imported schema available as
+// `common.block_header.tls`.
+//
+// importing holds the locations of the schemas being imported, ending with
+// location itself.
+func parseImports(body hcl.Body, location string, refs *schemaRefs, importing []string) hcl.Diagnostics {
+	content, _, diags := body.PartialContent(&hcl.BodySchema{
+		Blocks: []hcl.BlockHeaderSchema{{Type: "import", LabelNames: []string{"name"}}},
+	})
+
+	seen := make(map[string]*hcl.Block)
+	for _, block := range content.Blocks {
+		name := block.Labels[0]
+		if !hclsyntax.ValidIdentifier(name) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "invalid import name",
+				Detail:   fmt.Sprintf("import name '%s' must be a valid identifier", name),
+				Subject:  block.LabelRanges[0].Ptr(),
+			})
+			continue
+		}
+		if prev, ok := seen[name]; ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "duplicate import",
+				Detail:   fmt.Sprintf("import '%s' is already declared", name),
+				Subject:  block.LabelRanges[0].Ptr(),
+				Extra:    &RelatedRange{Range: prev.LabelRanges[0], Message: "previously declared here"},
+			})
+			continue
+		}
+		seen[name] = block
+
+		importContent, d := block.Body.Content(refs.dialect.imports)
+		diags = append(diags, d...)
+		if d.HasErrors() {
+			continue
+		}
+		a := importContent.Attributes["source"]
+		val, d := a.Expr.Value(schemaEvalContext)
+		diags = append(diags, d...)
+		if d.HasErrors() {
+			continue
+		}
+		if val.IsNull() || val.Type() != cty.String {
+			diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid 'source'", Detail: "'source' must be a string", Subject: a.Expr.Range().Ptr()})
+			continue
+		}
+
+		src, source, d := refs.loader.open(val.AsString(), location)
+		for _, diag := range d {
+			diag.Subject = a.Expr.Range().Ptr()
+		}
+		diags = append(diags, d...)
+		if refs.loader.ctx.Err() != nil {
+			return diags
+		}
+		if d.HasErrors() {
+			continue
+		}
+		if slices.ContainsFunc(importing, func(l string) bool { return sameSchemaLocation(l, source) }) {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "import cycle",
+				Detail:   fmt.Sprintf("schema '%s' imports itself: %s", source, strings.Join(append(importing, source), " -> ")),
+				Subject:  a.Expr.Range().Ptr(),
+			})
+			continue
+		}
+
+		_, imported, d := refs.loader.parse(src, source, importing)
+		diags = append(diags, d...)
+		if refs.loader.ctx.Err() != nil {
+			return diags
+		}
+		if d.HasErrors() {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "invalid import",
+				Detail:   fmt.Sprintf("schema '%s' imported as '%s' has errors", source, name),
+				Subject:  a.Expr.Range().Ptr(),
+			})
+			continue
+		}
+		for key, fbs := range imported.bodies {
+			refs.bodies[name+"."+key] = fbs
+		}
+		for key, attr := range imported.attributes {
+			refs.attributes[name+"."+key] = attr
+		}
+	}
+
+	return diags
+}
+
+func sameSchemaLocation(a, b string) bool {
+	if hasScheme(a) || hasScheme(b) {
+		return a == b
+	}
+	return filepath.Clean(a) == filepath.Clean(b)
+}
@@ -0,0 +1,295 @@
+package hclschema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Lint rule IDs. They are stable, so tools can filter findings by rule.
+const (
+	// LintDuplicateAttribute reports an attribute declared twice in a body.
+	// Both declarations apply, so conflicting ones reject every value.
+	LintDuplicateAttribute = "duplicate-attribute"
+	// LintUnreachableBlockHeader reports a block_header with the same type,
+	// label count and match_label rules as an earlier one, which blocks are
+	// never validated against.
+	LintUnreachableBlockHeader = "unreachable-block-header"
+	// LintShadowedSpreadMember reports an attribute or block_header declared
+	// by more than one `body` block of the schema root.
+	LintShadowedSpreadMember = "shadowed-spread-member"
+	// LintUnusedID reports an id no `ref` or `extends` of the schema refers
+	// to. Schemas meant to be imported, which have no root `body` or are
+	// linted with LintOptions.Exported, export their ids and aren't checked.
+	LintUnusedID = "unused-id"
+	// LintEmptyLabelName reports an empty entry of `label_names`.
+	LintEmptyLabelName = "empty-label-name"
+	// LintNonBoolRequired reports a `required` that isn't a bool, which is
+	// ignored.
+	LintNonBoolRequired = "non-bool-required"
+)
+
+// LintFinding is set as the Extra of the diagnostics returned by
+// LintSchemaFile, and identifies the rule that produced them. Related, if
+// set, can also be retrieved with hcl.DiagnosticExtra[*RelatedRange].
+type LintFinding struct {
+	Rule    string
+	Related *RelatedRange
+}
+
+func (f *LintFinding) UnwrapDiagnosticExtra() interface{} {
+	if f.Related == nil {
+		return nil
+	}
+	return f.Related
+}
+
+// LintOptions configures LintSchemaFile.
+type LintOptions struct {
+	// Exported marks the schema as imported by other schemas, which refer to
+	// its ids, so they aren't reported as unused.
+	Exported bool
+}
+
+// LintSchemaFile reports mistakes in the schema file at filename that are
+// accepted by ParseSchemaFile but most likely don't do what the author
+// meant. Every finding carries a *LintFinding as its Extra.
+//
+// A schema that fails to parse isn't linted, and the diagnostics of
+// ParseSchemaFile are returned instead.
+func LintSchemaFile(filename string, opts LintOptions) hcl.Diagnostics {
+	if _, diags := ParseSchemaFile(filename); diags.HasErrors() {
+		return diags
+	}
+
+	file, diags := hclparse.NewParser().ParseHCLFile(filename)
+	if diags.HasErrors() {
+		return diags
+	}
+	d, diags := detectDialect(file.Body)
+	if diags.HasErrors() {
+		return diags
+	}
+
+	l := &linter{dialect: d, used: make(map[string]bool), exported: opts.Exported}
+	l.lintRoot(file.Body)
+	return l.diags
+}
+
+// linter walks the syntax of a schema file, which parsing doesn't keep the
+// ranges of.
+type linter struct {
+	dialect *dialect
+	diags   hcl.Diagnostics
+
+	// ids holds the id attributes declared in the schema and used the keys
+	// refs and extends point at.
+	ids  []*hcl.Attribute
+	used map[string]bool
+	// exported is set if other schemas import the one being linted.
+	exported bool
+}
+
+// lintMember is an attribute or block_header of a body, keyed by what
+// validation tells them apart by.
+type lintMember struct {
+	key  string
+	desc string
+	rng  hcl.Range
+}
+
+func (l *linter) report(rule string, severity hcl.DiagnosticSeverity, summary, detail string, rng hcl.Range, related *RelatedRange) {
+	l.diags = append(l.diags, &hcl.Diagnostic{
+		Severity: severity,
+		Summary:  summary,
+		Detail:   detail,
+		Subject:  rng.Ptr(),
+		Extra:    &LintFinding{Rule: rule, Related: related},
+	})
+}
+
+func (l *linter) lintRoot(body hcl.Body) {
+	content, _, _ := body.PartialContent(l.dialect.root)
+
+	spread := make(map[string]lintMember)
+	hasBody := false
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "body":
+			hasBody = true
+			members := l.lintBody(block.Body)
+			for _, m := range members {
+				if prev, ok := spread[m.key]; ok {
+					// Every declaration of an attribute applies, while blocks
+					// are validated against the first matching block_header.
+					detail := fmt.Sprintf("%s is already declared by another root body, which takes precedence", m.desc)
+					if strings.HasPrefix(m.key, "attribute.") {
+						detail = fmt.Sprintf("%s is already declared by another root body; both declarations apply, so values must satisfy both and conflicting constraints reject every value", m.desc)
+					}
+					l.report(LintShadowedSpreadMember, hcl.DiagWarning,
+						"shadowed spread member",
+						detail,
+						m.rng, &RelatedRange{Range: prev.rng, Message: "previously declared here"})
+				}
+			}
+			// Duplicates within a body are reported by lintBody.
+			for _, m := range members {
+				if _, ok := spread[m.key]; !ok {
+					spread[m.key] = m
+				}
+			}
+		case "definitions":
+			defs, _, _ := block.Body.PartialContent(l.dialect.definitions)
+			for _, def := range defs.Blocks {
+				switch def.Type {
+				case "body":
+					l.lintBody(def.Body)
+				case "attribute":
+					l.lintAttribute(def.Body)
+				}
+			}
+		}
+	}
+
+	if !hasBody || l.exported {
+		// The ids of schemas meant to be imported are used by the schemas
+		// importing them.
+		return
+	}
+	for _, a := range l.ids {
+		id, _ := parseRefKey(a)
+		if id != "" && !l.used["block_header."+id] {
+			l.report(LintUnusedID, hcl.DiagWarning,
+				"unused id",
+				fmt.Sprintf("id '%s' is not referred to by any 'ref' or 'extends' of this schema", id),
+				a.Expr.Range(), nil)
+		}
+	}
+}
+
+// lintBody lints a body and the bodies nested in it, and returns its members.
+func (l *linter) lintBody(body hcl.Body) []lintMember {
+	content, _, _ := body.PartialContent(l.dialect.body)
+
+	var members []lintMember
+	seen := make(map[string]lintMember)
+	for _, block := range content.Blocks {
+		switch block.Type {
+		case "attribute":
+			l.lintAttribute(block.Body)
+			m := lintMember{key: "attribute." + block.Labels[0], desc: fmt.Sprintf("attribute '%s'", block.Labels[0]), rng: block.LabelRanges[0]}
+			if prev, ok := seen[m.key]; ok {
+				l.report(LintDuplicateAttribute, hcl.DiagError,
+					"duplicate attribute",
+					fmt.Sprintf("%s is already declared in this body; both declarations apply, so values must satisfy both and conflicting constraints reject every value", m.desc),
+					m.rng, &RelatedRange{Range: prev.rng, Message: "previously declared here"})
+				continue
+			}
+			seen[m.key] = m
+			members = append(members, m)
+
+		case "attribute_pattern":
+			l.lintAttribute(block.Body)
+
+		case "block_header":
+			m := l.lintBlockHeader(block)
+			if prev, ok := seen[m.key]; ok {
+				l.report(LintUnreachableBlockHeader, hcl.DiagError,
+					"unreachable block_header",
+					fmt.Sprintf("%s is already declared in this body, so blocks are never validated against this declaration", m.desc),
+					m.rng, &RelatedRange{Range: prev.rng, Message: "previously declared here"})
+				continue
+			}
+			seen[m.key] = m
+			members = append(members, m)
+		}
+	}
+	return members
+}
+
+// lintBlockHeader lints a block_header block and returns it as a member,
+// keyed by its type, label count and match_label rules like findBlockDef
+// tells definitions apart.
+func (l *linter) lintBlockHeader(block *hcl.Block) lintMember {
+	content, _, _ := block.Body.PartialContent(l.dialect.blockHeader)
+	typ := block.Labels[0]
+
+	labelCount := 0
+	if a, ok := content.Attributes["label_names"]; ok {
+		if val, d := a.Expr.Value(schemaEvalContext); !d.HasErrors() && val.CanIterateElements() {
+			labelCount = val.LengthInt()
+			for it := val.ElementIterator(); it.Next(); {
+				_, v := it.Element()
+				if v.Type() == cty.String && v.IsKnown() && !v.IsNull() && strings.TrimSpace(v.AsString()) == "" {
+					l.report(LintEmptyLabelName, hcl.DiagError,
+						"empty label name",
+						fmt.Sprintf("'label_names' of block_header '%s' has an empty entry", typ),
+						a.Expr.Range(), nil)
+				}
+			}
+		}
+	}
+
+	if a, ok := content.Attributes["id"]; ok {
+		l.ids = append(l.ids, a)
+	}
+	for _, name := range []string{"ref", "extends"} {
+		if a, ok := content.Attributes[name]; ok {
+			if key, _ := parseRefKey(a); key != "" {
+				l.used[key] = true
+			}
+		}
+	}
+
+	var matches []string
+	for _, inner := range content.Blocks {
+		switch inner.Type {
+		case "body":
+			l.lintBody(inner.Body)
+		case "match_label":
+			mc, _, _ := inner.Body.PartialContent(l.dialect.matchLabel)
+			var index, value cty.Value
+			if a, ok := mc.Attributes["index"]; ok {
+				index, _ = a.Expr.Value(schemaEvalContext)
+			}
+			if a, ok := mc.Attributes["value"]; ok {
+				value, _ = a.Expr.Value(schemaEvalContext)
+			}
+			matches = append(matches, fmt.Sprintf("%#v=%#v", index, value))
+		}
+	}
+	slices.Sort(matches)
+
+	return lintMember{
+		key:  fmt.Sprintf("block_header.%s/%d/%s", typ, labelCount, strings.Join(matches, ",")),
+		desc: fmt.Sprintf("block_header '%s' with %d label(s)", typ, labelCount),
+		rng:  block.LabelRanges[0],
+	}
+}
+
+func (l *linter) lintAttribute(body hcl.Body) {
+	content, _, _ := body.PartialContent(l.dialect.attribute)
+
+	if a, ok := content.Attributes["ref"]; ok {
+		if key, _ := parseRefKey(a); key != "" {
+			l.used[key] = true
+		}
+	}
+	if a, ok := content.Attributes["required"]; ok {
+		val, d := a.Expr.Value(schemaEvalContext)
+		if !d.HasErrors() && val.IsKnown() && (val.IsNull() || val.Type() != cty.Bool) {
+			got := "null"
+			if !val.IsNull() {
+				got = val.Type().FriendlyName()
+			}
+			l.report(LintNonBoolRequired, hcl.DiagError,
+				"non-bool 'required'",
+				fmt.Sprintf("'required' must be a bool, got %s; the attribute is treated as optional", got),
+				a.Expr.Range(), nil)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package hclschema
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+func TestLintSchemaFile(t *testing.T) {
+	diags := LintSchemaFile(filepath.Join("testdata", "lint.schema.hcl"), LintOptions{})
+
+	type finding struct {
+		rule     string
+		line     int
+		severity hcl.DiagnosticSeverity
+	}
+	want := []finding{
+		{LintNonBoolRequired, 6, hcl.DiagError},
+		{LintDuplicateAttribute, 8, hcl.DiagError},
+		{LintUnreachableBlockHeader, 13, hcl.DiagError},
+		{LintEmptyLabelName, 17, hcl.DiagError},
+		{LintShadowedSpreadMember, 37, hcl.DiagWarning},
+		{LintShadowedSpreadMember, 38, hcl.DiagWarning},
+		{LintUnusedID, 18, hcl.DiagWarning},
+	}
+	var got []finding
+	for _, d := range diags {
+		f, ok := hcl.DiagnosticExtra[*LintFinding](d)
+		if !ok {
+			t.Fatalf("expected a lint finding, got %v", d)
+		}
+		got = append(got, finding{f.Rule, d.Subject.Start.Line, d.Severity})
+	}
+	if len(got) != len(want) {
+		t.Fatalf("expected %d findings, got %d: %v", len(want), len(got), diags)
+	}
+	for i := range want {
+		if got[i] != want[i] {
+			t.Errorf("finding %d: expected %+v, got %+v", i, want[i], got[i])
+		}
+	}
+
+	for _, d := range diags {
+		f, _ := hcl.DiagnosticExtra[*LintFinding](d)
+		rel, ok := hcl.DiagnosticExtra[*RelatedRange](d)
+		if ok != (f.Related != nil) {
+			t.Errorf("%s: expected RelatedRange to be found only when set", f.Rule)
+		}
+		if f.Rule == LintDuplicateAttribute && (!ok || rel.Range.Start.Line != 5) {
+			t.Errorf("expected duplicate attribute to relate to line 5, got %v", rel)
+		}
+		// Every declaration of an attribute applies; only the first
+		// matching block_header does.
+		attribute := strings.Contains(d.Detail, "attribute '")
+		if f.Rule == LintDuplicateAttribute || f.Rule == LintShadowedSpreadMember {
+			if applies := strings.Contains(d.Detail, "both declarations apply"); applies != attribute {
+				t.Errorf("%s: unexpected detail %q", f.Rule, d.Detail)
+			}
+		}
+	}
+}
+
+func TestLintSchemaFile_Clean(t *testing.T) {
+	diags := LintSchemaFile(filepath.Join("testdata", "lint_clean.schema.hcl"), LintOptions{})
+	if len(diags) > 0 {
+		t.Fatalf("expected no findings, got: %v", diags)
+	}
+}
+
+func TestLintSchemaFile_InvalidSchema(t *testing.T) {
+	diags := LintSchemaFile(filepath.Join("testdata", "meta_violations.schema.hcl"), LintOptions{})
+	if !diags.HasErrors() {
+		t.Fatalf("expected the parse errors of an invalid schema")
+	}
+	for _, d := range diags {
+		if _, ok := hcl.DiagnosticExtra[*LintFinding](d); ok {
+			t.Fatalf("expected no lint findings for an invalid schema, got %v", d)
+		}
+	}
+}
+
+func TestLintSchemaFile_ImportedIDs(t *testing.T) {
+	// Imported by import.schema.hcl, whose refs use the id.
+	common := filepath.Join("testdata", "import_common.schema.hcl")
+	if diags := LintSchemaFile(common, LintOptions{}); len(diags) != 1 || diags[0].Summary != "unused id" {
+		t.Fatalf("expected the id to be reported unless exported, got: %v", diags)
+	}
+	if diags := LintSchemaFile(common, LintOptions{Exported: true}); len(diags) > 0 {
+		t.Fatalf("expected the ids of an exported schema not to be reported, got: %v", diags)
+	}
+
+	// Without a root body, the schema is only useful imported.
+	path := filepath.Join(t.TempDir(), "library.schema.hcl")
+	src := `__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://library"
+
+definitions {
+    body "server" {
+        block_header "tls" {
+            id = "tls"
+        }
+    }
+}
+`
+	if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if diags := LintSchemaFile(path, LintOptions{}); len(diags) > 0 {
+		t.Fatalf("expected no findings, got: %v", diags)
+	}
+}
@@ -0,0 +1,103 @@
+package hclschema
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// Metadata documents an attribute, block or body of a schema. It doesn't
+// affect validation, except that using a deprecated element produces a
+// warning.
+type Metadata struct {
+	Description string
+	// Examples holds sample values, or for blocks and bodies, sample snippets.
+	Examples           []cty.Value
+	Deprecated         bool
+	DeprecationMessage string
+}
+
+func parseMetadata(attrs hcl.Attributes, ctx *hcl.EvalContext) (Metadata, hcl.Diagnostics) {
+	var md Metadata
+	var diags hcl.Diagnostics
+
+	evalString := func(name string) string {
+		a, ok := attrs[name]
+		if !ok {
+			return ""
+		}
+		val, d := a.Expr.Value(ctx)
+		diags = append(diags, d...)
+		if d.HasErrors() {
+			return ""
+		}
+		if val.IsNull() || val.Type() != cty.String {
+			diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: fmt.Sprintf("invalid '%s'", name), Detail: fmt.Sprintf("'%s' must be a string", name), Subject: a.Expr.Range().Ptr()})
+			return ""
+		}
+		return val.AsString()
+	}
+
+	md.Description = evalString("description")
+	md.DeprecationMessage = evalString("deprecation_message")
+
+	if a, ok := attrs["examples"]; ok {
+		val, d := a.Expr.Value(ctx)
+		diags = append(diags, d...)
+		if !d.HasErrors() {
+			if val.IsNull() || !val.IsKnown() || !(val.Type().IsListType() || val.Type().IsTupleType() || val.Type().IsSetType()) {
+				diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid 'examples'", Detail: "'examples' must be a list of values", Subject: a.Expr.Range().Ptr()})
+			} else {
+				for it := val.ElementIterator(); it.Next(); {
+					_, v := it.Element()
+					md.Examples = append(md.Examples, v)
+				}
+			}
+		}
+	}
+
+	if a, ok := attrs["deprecated"]; ok {
+		val, d := a.Expr.Value(ctx)
+		diags = append(diags, d...)
+		if !d.HasErrors() {
+			if val.IsNull() || val.Type() != cty.Bool {
+				diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid 'deprecated'", Detail: "'deprecated' must be a bool", Subject: a.Expr.Range().Ptr()})
+			} else {
+				md.Deprecated = val.True()
+			}
+		}
+	}
+	// A deprecation message on its own implies deprecation.
+	if md.DeprecationMessage != "" {
+		if _, ok := attrs["deprecated"]; !ok {
+			md.Deprecated = true
+		}
+	}
+
+	return md, diags
+}
+
+// isZero reports whether no metadata was declared.
+func (md *Metadata) isZero() bool {
+	return md.Description == "" && len(md.Examples) == 0 && !md.Deprecated && md.DeprecationMessage == ""
+}
+
+// deprecationWarning returns the warning emitted when an element described by
+// md is used, or nil when it isn't deprecated. kind and name identify the
+// element in the message, e.g. "attribute" and "port".
+func (md *Metadata) deprecationWarning(kind, name string, rng hcl.Range) *hcl.Diagnostic {
+	if !md.Deprecated {
+		return nil
+	}
+	detail := fmt.Sprintf("%s '%s' is deprecated", kind, name)
+	if md.DeprecationMessage != "" {
+		detail += ": " + md.DeprecationMessage
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagWarning,
+		Summary:  fmt.Sprintf("deprecated %s", kind),
+		Detail:   detail,
+		Subject:  rng.Ptr(),
+	}
+}
@@ -0,0 +1,343 @@
+package hclschema
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// schemaRefs holds what the `ref` attributes of a schema file can point at,
+// keyed by the traversal that refers to them, e.g. `block_header.tls` or
+// `definitions.attribute.port`, along with the dialect the file is written
+// in.
+//
+// Every id and definition of a schema file is collected by collectRefs before
+// the file is parsed, so refs don't depend on declaration order. Bodies start
+// out as empty placeholders that are filled in once parsed, which also lets a
+// body refer to itself.
+type schemaRefs struct {
+	dialect *dialect
+	// loader loads the schemas the file imports.
+	loader *schemaLoader
+	// id is the `__id` of the schema file and src its source.
+	id  string
+	src []byte
+
+	bodies     map[string]*FullBodySchema
+	attributes map[string]*FullAttributeSchema
+
+	// owners maps the key of every body placeholder to the DefRange of the
+	// block that fills it in, so that duplicates don't.
+	owners map[string]hcl.Range
+	// pending holds the attribute definitions that haven't been parsed yet
+	// and resolving the ones being parsed, to detect ref cycles.
+	pending   map[string]*hcl.Block
+	resolving map[string]bool
+	// extensions holds the bodies to merge with the bodies they extend once
+	// the schema is parsed.
+	extensions []*bodyExtension
+	// ruleChecks holds the names that the attribute rules of the file refer
+	// to, checked by checkRuleNames.
+	ruleChecks []ruleCheck
+}
+
+func newSchemaRefs(dialect *dialect, loader *schemaLoader) *schemaRefs {
+	return &schemaRefs{
+		dialect:    dialect,
+		loader:     loader,
+		bodies:     make(map[string]*FullBodySchema),
+		attributes: make(map[string]*FullAttributeSchema),
+		owners:     make(map[string]hcl.Range),
+		pending:    make(map[string]*hcl.Block),
+		resolving:  make(map[string]bool),
+	}
+}
+
+// parseSchemaID sets refs.id to the `__id` of a schema root body.
+func parseSchemaID(body hcl.Body, refs *schemaRefs) hcl.Diagnostics {
+	content, _, _ := body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "__id"}},
+	})
+	a, ok := content.Attributes["__id"]
+	if !ok {
+		return nil
+	}
+	val, diags := a.Expr.Value(schemaEvalContext)
+	if diags.HasErrors() {
+		return diags
+	}
+	if val.IsNull() || val.Type() != cty.String {
+		return append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid '__id'", Detail: "'__id' must be a string", Subject: a.Expr.Range().Ptr()})
+	}
+	refs.id = val.AsString()
+	return diags
+}
+
+// idAlias is a block_header with an `id` and a `ref` but no `body`, whose id
+// stands for the body it refers to.
+type idAlias struct {
+	key    string
+	target string
+	ref    *hcl.Attribute
+}
+
+// collectRefs declares every id and definition of a schema root body in refs.
+// Duplicate declarations and cycles of ids referring to each other are
+// reported; refs to unknown keys are left for parseBody to report where they
+// are used.
+func collectRefs(body hcl.Body, refs *schemaRefs) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	declared := make(map[string]hcl.Range)
+	aliases := make([]idAlias, 0)
+
+	declare := func(key, kind, name string, rng hcl.Range) bool {
+		if prev, ok := declared[key]; ok {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  fmt.Sprintf("duplicate %s", kind),
+				Detail:   fmt.Sprintf("%s '%s' is already declared", kind, name),
+				Subject:  rng.Ptr(),
+				Extra:    &RelatedRange{Range: prev, Message: "previously declared here"},
+			})
+			return false
+		}
+		declared[key] = rng
+		return true
+	}
+
+	// Problems other than duplicates and cycles are reported by parseBody, so
+	// the diagnostics of PartialContent are dropped.
+	var walk func(body hcl.Body)
+	walk = func(body hcl.Body) {
+		content, _, _ := body.PartialContent(refs.dialect.body)
+		for _, block := range content.Blocks {
+			if block.Type != "block_header" {
+				continue
+			}
+			inner, _, _ := block.Body.PartialContent(refs.dialect.blockHeader)
+			hasBody := false
+			for _, nb := range inner.Blocks {
+				if nb.Type == "body" {
+					hasBody = true
+					walk(nb.Body)
+				}
+			}
+
+			a, ok := inner.Attributes["id"]
+			if !ok {
+				continue
+			}
+			id, _ := parseRefKey(a)
+			if id == "" {
+				continue
+			}
+			key := "block_header." + id
+			if !declare(key, "id", id, a.Expr.Range()) {
+				continue
+			}
+			if ref, ok := inner.Attributes["ref"]; ok && !hasBody {
+				target, _ := parseRefKey(ref)
+				aliases = append(aliases, idAlias{key: key, target: target, ref: ref})
+				continue
+			}
+			refs.bodies[key] = &FullBodySchema{}
+			refs.owners[key] = block.DefRange
+		}
+	}
+
+	root, _, _ := body.PartialContent(refs.dialect.root)
+	for _, block := range root.Blocks {
+		switch block.Type {
+		case "body":
+			walk(block.Body)
+		case "definitions":
+			defs, _, _ := block.Body.PartialContent(refs.dialect.definitions)
+			for _, def := range defs.Blocks {
+				key := fmt.Sprintf("definitions.%s.%s", def.Type, def.Labels[0])
+				if !declare(key, "definition", def.Labels[0], def.LabelRanges[0]) {
+					continue
+				}
+				switch def.Type {
+				case "body":
+					walk(def.Body)
+					refs.bodies[key] = &FullBodySchema{}
+					refs.owners[key] = def.DefRange
+				case "attribute":
+					refs.pending[key] = def
+				}
+			}
+		}
+	}
+
+	diags = append(diags, resolveIDAliases(aliases, refs)...)
+	return diags
+}
+
+// resolveIDAliases points every alias at the body its chain of refs ends at.
+// A cycle of aliases is reported once, at the first alias of the cycle, and
+// its aliases get an empty body.
+func resolveIDAliases(aliases []idAlias, refs *schemaRefs) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	targets := make(map[string]string, len(aliases))
+	for _, a := range aliases {
+		targets[a.key] = a.target
+	}
+
+	for _, a := range aliases {
+		chain := []string{a.key}
+		target := a.target
+		for {
+			if fbs, ok := refs.bodies[target]; ok {
+				refs.bodies[a.key] = fbs
+				break
+			}
+			next, ok := targets[target]
+			if !ok {
+				// Reported as an unresolved ref by parseBody.
+				break
+			}
+			if target == a.key {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "ref cycle",
+					Detail:   fmt.Sprintf("'%s' refers to itself: %s", a.key, strings.Join(append(chain, target), " -> ")),
+					Subject:  a.ref.Range.Ptr(),
+				})
+				refs.bodies[a.key] = &FullBodySchema{}
+				break
+			}
+			if len(chain) > len(aliases) {
+				// The chain leads into a cycle that doesn't include a.key,
+				// which is reported on its own.
+				refs.bodies[a.key] = &FullBodySchema{}
+				break
+			}
+			chain = append(chain, target)
+			target = next
+		}
+	}
+	return diags
+}
+
+// attribute returns the attribute definition with the given key, parsing it
+// first if needed. It returns false if there's no such definition. rng is the
+// range of the ref, where a cycle is reported.
+func (r *schemaRefs) attribute(key string, rng hcl.Range) (*FullAttributeSchema, bool, hcl.Diagnostics) {
+	if attr, ok := r.attributes[key]; ok {
+		return attr, true, nil
+	}
+	block, ok := r.pending[key]
+	if !ok {
+		return nil, false, nil
+	}
+	if r.resolving[key] {
+		return nil, true, hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "ref cycle",
+			Detail:   fmt.Sprintf("'%s' refers to itself", key),
+			Subject:  rng.Ptr(),
+		}}
+	}
+
+	r.resolving[key] = true
+	checks := len(r.ruleChecks)
+	attr, diags := parseAttribute(block.Labels[0], block.Body, schemaEvalContext, r)
+	// The rules of a definition are checked where it's referred to.
+	r.ruleChecks = r.ruleChecks[:checks]
+	delete(r.resolving, key)
+	delete(r.pending, key)
+	r.attributes[key] = &attr
+	return &attr, true, diags
+}
+
+// resolveAttributeRef returns the attribute definition referred to by the
+// `ref` of an attribute block, renamed to name. An attribute with a `ref`
+// can't declare anything else.
+func resolveAttributeRef(name string, content *hcl.BodyContent, refs *schemaRefs) (FullAttributeSchema, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	a := content.Attributes["ref"]
+
+	if len(content.Attributes) > 1 || len(content.Blocks) > 0 {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "invalid 'ref'",
+			Detail:   fmt.Sprintf("attribute '%s' can't declare other properties together with 'ref'", name),
+			Subject:  a.Range.Ptr(),
+		})
+	}
+
+	refKey, d := parseRefKey(a)
+	diags = append(diags, d...)
+	if refKey == "" {
+		return FullAttributeSchema{}, diags
+	}
+	def, ok, d := refs.attribute(refKey, a.Range)
+	diags = append(diags, d...)
+	if !ok {
+		return FullAttributeSchema{}, append(diags, unresolvedRefDiagnostic(refKey, a))
+	}
+	if def == nil {
+		return FullAttributeSchema{}, diags
+	}
+
+	attr := *def
+	attr.Name = name
+	if r := def.AttributeRules; !r.isZero() {
+		names := slices.Concat(slices.Concat(r.OneOf, r.ExactlyOneOf, r.ConflictsWith, r.RequiredWith)...)
+		refs.ruleChecks = append(refs.ruleChecks, ruleCheck{attr: a, names: names})
+	}
+	return attr, diags
+}
+
+// parseRefKey returns the reference path of a `ref` or `id` attribute, such as
+// `block_header.tls`. It may be written as a traversal or as a string.
+func parseRefKey(a *hcl.Attribute) (string, hcl.Diagnostics) {
+	if traversal, d := hcl.AbsTraversalForExpr(a.Expr); !d.HasErrors() {
+		parts := make([]string, 0, len(traversal))
+		for _, step := range traversal {
+			switch step := step.(type) {
+			case hcl.TraverseRoot:
+				parts = append(parts, step.Name)
+			case hcl.TraverseAttr:
+				parts = append(parts, step.Name)
+			case hcl.TraverseIndex:
+				if step.Key.Type() == cty.String {
+					parts = append(parts, step.Key.AsString())
+					continue
+				}
+				return "", hcl.Diagnostics{invalidRefKeyDiagnostic(a)}
+			}
+		}
+		return strings.Join(parts, "."), nil
+	}
+
+	val, diags := a.Expr.Value(schemaEvalContext)
+	if diags.HasErrors() {
+		return "", diags
+	}
+	if val.IsNull() || val.Type() != cty.String {
+		return "", hcl.Diagnostics{invalidRefKeyDiagnostic(a)}
+	}
+	return val.AsString(), nil
+}
+
+func invalidRefKeyDiagnostic(a *hcl.Attribute) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  fmt.Sprintf("invalid '%s'", a.Name),
+		Detail:   fmt.Sprintf("'%s' must be a reference such as block_header.name, or a string", a.Name),
+		Subject:  a.Expr.Range().Ptr(),
+	}
+}
+
+func unresolvedRefDiagnostic(refKey string, a *hcl.Attribute) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "unresolved ref",
+		Detail:   fmt.Sprintf("ref '%s' not found", refKey),
+		Subject:  a.Range.Ptr(),
+	}
+}
@@ -0,0 +1,285 @@
+package hclschema
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/url"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// SchemaResolver locates the schema files referred to by the `__schema` of
+// HCL files and the `source` of schema imports.
+//
+// Resolve opens the schema ref refers to. baseURI is the canonical URI of the
+// file ref is found in, which relative refs are resolved against, or empty.
+// Resolve returns the canonical URI of the schema, which names it in
+// diagnostics and is the base of the refs found in it. A resolver that
+// doesn't handle ref returns an error wrapping ErrSchemaNotFound.
+type SchemaResolver interface {
+	Resolve(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error)
+}
+
+// ErrSchemaNotFound is returned by resolvers for the refs they can't
+// resolve, so that ChainResolver tries the next resolver.
+var ErrSchemaNotFound = errors.New("schema not found")
+
+// ResolverFunc adapts a function to a SchemaResolver.
+type ResolverFunc func(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error)
+
+func (f ResolverFunc) Resolve(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error) {
+	return f(ctx, ref, baseURI)
+}
+
+// DefaultResolver returns the resolver used when none is given: local paths
+// are read from disk and https URLs are downloaded, within 15 seconds, and
+// cached for a day in the temporary directory.
+func DefaultResolver() SchemaResolver {
+	return ChainResolver{
+		FileResolver{},
+		&HTTPSResolver{
+			CacheDir: filepath.Join(os.TempDir(), "hclschema-cache"),
+			Timeout:  15 * time.Second,
+		},
+	}
+}
+
+// ChainResolver resolves refs with the first of its resolvers that handles
+// them.
+type ChainResolver []SchemaResolver
+
+func (c ChainResolver) Resolve(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error) {
+	var notFound error
+	for _, r := range c {
+		rc, uri, err := r.Resolve(ctx, ref, baseURI)
+		if err == nil || !errors.Is(err, ErrSchemaNotFound) {
+			return rc, uri, err
+		}
+		// Keep the first specific reason, such as a missing file.
+		if notFound == nil || notFound == ErrSchemaNotFound {
+			notFound = err
+		}
+	}
+	if notFound == nil || notFound == ErrSchemaNotFound {
+		return nil, "", fmt.Errorf("%w: %s", ErrSchemaNotFound, ref)
+	}
+	return nil, "", notFound
+}
+
+// FileResolver reads schemas from disk. Relative paths are resolved against
+// the directory of the base URI, which must be a local path. Refs with a
+// scheme, and any ref found in a file that has one, are not handled, so that
+// a downloaded schema can't read local files.
+type FileResolver struct{}
+
+func (FileResolver) Resolve(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error) {
+	if hasScheme(ref) || hasScheme(baseURI) {
+		return nil, "", ErrSchemaNotFound
+	}
+	name := ref
+	if !filepath.IsAbs(name) && baseURI != "" {
+		name = filepath.Join(filepath.Dir(baseURI), name)
+	}
+	f, err := os.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", fmt.Errorf("%w: %w", ErrSchemaNotFound, err)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return f, name, nil
+}
+
+// HTTPSResolver downloads schemas from https URLs, including relative refs
+// found in schemas downloaded from one. Plain http URLs are rejected.
+type HTTPSResolver struct {
+	// Client defaults to http.DefaultClient.
+	Client *http.Client
+	// CacheDir, if set, holds the downloaded schemas, which are reused for a
+	// day.
+	CacheDir string
+	// Timeout, if set, bounds each download, on top of the deadline of the
+	// context passed to Resolve.
+	Timeout time.Duration
+}
+
+// maxRemoteSchemaSize bounds the size of downloaded schemas.
+const maxRemoteSchemaSize = 1 << 20
+
+func (r *HTTPSResolver) Resolve(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error) {
+	if !isRemoteRef(ref) && !(isRemoteRef(baseURI) && !filepath.IsAbs(ref)) {
+		return nil, "", ErrSchemaNotFound
+	}
+	uri, err := joinRef(ref, baseURI)
+	if err != nil {
+		return nil, "", err
+	}
+	if !strings.HasPrefix(uri, "https://") {
+		return nil, "", fmt.Errorf("insecure schema URL %s: only https:// URLs are allowed for remote schemas", uri)
+	}
+
+	var cached string
+	if r.CacheDir != "" {
+		h := sha256.Sum256([]byte(uri))
+		cached = filepath.Join(r.CacheDir, hex.EncodeToString(h[:])+SchemaExtension)
+		if fi, err := os.Stat(cached); err == nil && time.Since(fi.ModTime()) < 24*time.Hour {
+			f, err := os.Open(cached)
+			if err == nil {
+				return f, uri, nil
+			}
+		}
+	}
+
+	data, err := r.download(ctx, uri)
+	if err != nil {
+		return nil, "", err
+	}
+	if cached != "" {
+		if err := writeCacheFile(cached, data); err != nil {
+			return nil, "", fmt.Errorf("failed to cache schema %s: %w", uri, err)
+		}
+	}
+	return io.NopCloser(bytes.NewReader(data)), uri, nil
+}
+
+func (r *HTTPSResolver) download(ctx context.Context, uri string) ([]byte, error) {
+	client := r.Client
+	if client == nil {
+		client = http.DefaultClient
+	}
+
+	if r.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, r.Timeout)
+		defer cancel()
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, uri, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to download schema: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to download schema %s: %s", uri, resp.Status)
+	}
+
+	data, err := io.ReadAll(io.LimitReader(resp.Body, maxRemoteSchemaSize+1))
+	if err != nil {
+		return nil, fmt.Errorf("failed to read schema %s: %w", uri, err)
+	}
+	if len(data) > maxRemoteSchemaSize {
+		return nil, fmt.Errorf("schema %s exceeds the maximum size of %d bytes", uri, maxRemoteSchemaSize)
+	}
+	return data, nil
+}
+
+// writeCacheFile writes data to name through a temporary file, so that
+// concurrent readers never see a partial schema.
+func writeCacheFile(name string, data []byte) error {
+	if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+		return err
+	}
+	f, err := os.CreateTemp(filepath.Dir(name), "tmp-*"+SchemaExtension)
+	if err != nil {
+		return err
+	}
+	_, err = f.Write(data)
+	if cerr := f.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(f.Name(), name)
+	}
+	if err != nil {
+		os.Remove(f.Name())
+	}
+	return err
+}
+
+// FSResolver reads schemas from a file system, such as an embed.FS. Refs
+// starting with Prefix, like `schemas://` for `schemas://payments/v2`, name
+// the file at the rest of the ref. Without a Prefix, refs that have no scheme
+// are handled. Relative refs are resolved against the base URI.
+type FSResolver struct {
+	FS     fs.FS
+	Prefix string
+}
+
+func (r FSResolver) Resolve(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error) {
+	uri, err := joinRef(ref, baseURI)
+	if err != nil {
+		return nil, "", err
+	}
+	if (r.Prefix == "" && hasScheme(uri)) || !strings.HasPrefix(uri, r.Prefix) {
+		return nil, "", ErrSchemaNotFound
+	}
+	name := strings.TrimPrefix(strings.TrimPrefix(uri, r.Prefix), "/")
+	if !fs.ValidPath(name) {
+		return nil, "", fmt.Errorf("%w: invalid path %s", ErrSchemaNotFound, name)
+	}
+	f, err := r.FS.Open(name)
+	if errors.Is(err, fs.ErrNotExist) {
+		return nil, "", fmt.Errorf("%w: %w", ErrSchemaNotFound, err)
+	}
+	if err != nil {
+		return nil, "", err
+	}
+	return f, uri, nil
+}
+
+// MapResolver resolves refs to the schema sources it holds, keyed by URI.
+// Relative refs are resolved against the base URI.
+type MapResolver map[string][]byte
+
+func (m MapResolver) Resolve(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error) {
+	uri, err := joinRef(ref, baseURI)
+	if err != nil {
+		return nil, "", err
+	}
+	src, ok := m[uri]
+	if !ok {
+		return nil, "", fmt.Errorf("%w: %s", ErrSchemaNotFound, uri)
+	}
+	return io.NopCloser(bytes.NewReader(src)), uri, nil
+}
+
+// joinRef resolves ref against baseURI: as a URL reference if baseURI has a
+// scheme, and as a slash separated path otherwise.
+func joinRef(ref, baseURI string) (string, error) {
+	if baseURI == "" || hasScheme(ref) || path.IsAbs(ref) || filepath.IsAbs(ref) {
+		return ref, nil
+	}
+	if !hasScheme(baseURI) {
+		return path.Join(path.Dir(filepath.ToSlash(baseURI)), filepath.ToSlash(ref)), nil
+	}
+	base, err := url.Parse(baseURI)
+	if err != nil {
+		return "", fmt.Errorf("invalid schema reference: %w", err)
+	}
+	rel, err := url.Parse(filepath.ToSlash(ref))
+	if err != nil {
+		return "", fmt.Errorf("invalid schema reference: %w", err)
+	}
+	return base.ResolveReference(rel).String(), nil
+}
+
+func hasScheme(ref string) bool {
+	return strings.Contains(ref, "://")
+}
+
+func isRemoteRef(ref string) bool {
+	return strings.HasPrefix(ref, "http://") || strings.HasPrefix(ref, "https://")
+}
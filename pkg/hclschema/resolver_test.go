@@ -0,0 +1,113 @@
+package hclschema
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"testing/fstest"
+)
+
+func readResolved(t *testing.T, r SchemaResolver, ref, base string) (string, string) {
+	t.Helper()
+	rc, uri, err := r.Resolve(context.Background(), ref, base)
+	if err != nil {
+		t.Fatalf("Resolve(%q, %q) returned an error: %v", ref, base, err)
+	}
+	defer rc.Close()
+	src, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("failed to read %s: %v", uri, err)
+	}
+	return string(src), uri
+}
+
+func TestMapResolver(t *testing.T) {
+	r := MapResolver{"schemas://payments/v2": []byte("a"), "schemas://payments/common": []byte("b")}
+
+	if src, uri := readResolved(t, r, "schemas://payments/v2", "service.hcl"); src != "a" || uri != "schemas://payments/v2" {
+		t.Fatalf("unexpected resolution: %q from %s", src, uri)
+	}
+	if src, uri := readResolved(t, r, "./common", "schemas://payments/v2"); src != "b" || uri != "schemas://payments/common" {
+		t.Fatalf("expected the relative ref to resolve against the base, got %q from %s", src, uri)
+	}
+	if _, _, err := r.Resolve(context.Background(), "schemas://billing/v1", ""); !errors.Is(err, ErrSchemaNotFound) {
+		t.Fatalf("expected ErrSchemaNotFound, got %v", err)
+	}
+}
+
+func TestFSResolver(t *testing.T) {
+	r := FSResolver{
+		FS:     fstest.MapFS{"payments/v2": {Data: []byte("a")}},
+		Prefix: "schemas://",
+	}
+
+	if src, uri := readResolved(t, r, "schemas://payments/v2", ""); src != "a" || uri != "schemas://payments/v2" {
+		t.Fatalf("unexpected resolution: %q from %s", src, uri)
+	}
+	for _, ref := range []string{"schemas://payments/v3", "payments/v2", "https://example.com/payments/v2"} {
+		if _, _, err := r.Resolve(context.Background(), ref, ""); !errors.Is(err, ErrSchemaNotFound) {
+			t.Fatalf("expected ErrSchemaNotFound for %s, got %v", ref, err)
+		}
+	}
+
+	// A downloaded schema can't import local files, even by absolute path.
+	abs, err := filepath.Abs(filepath.Join("testdata", "simple.schema.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := (FileResolver{}).Resolve(context.Background(), abs, "https://example.com/schemas/a.schema.hcl"); !errors.Is(err, ErrSchemaNotFound) {
+		t.Fatalf("expected ErrSchemaNotFound for %s from a remote schema, got %v", abs, err)
+	}
+}
+
+func TestFileResolver(t *testing.T) {
+	base := filepath.Join("testdata", "multiple_tags.hcl")
+	want, err := os.ReadFile(filepath.Join("testdata", "simple.schema.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	src, uri := readResolved(t, FileResolver{}, "simple.schema.hcl", base)
+	if src != string(want) || uri != filepath.Join("testdata", "simple.schema.hcl") {
+		t.Fatalf("expected testdata/simple.schema.hcl, got %s", uri)
+	}
+	for _, ref := range []string{"missing.schema.hcl", "schemas://payments/v2"} {
+		if _, _, err := (FileResolver{}).Resolve(context.Background(), ref, base); !errors.Is(err, ErrSchemaNotFound) {
+			t.Fatalf("expected ErrSchemaNotFound for %s, got %v", ref, err)
+		}
+	}
+
+	// A downloaded schema can't import local files, even by absolute path.
+	abs, err := filepath.Abs(filepath.Join("testdata", "simple.schema.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, _, err := (FileResolver{}).Resolve(context.Background(), abs, "https://example.com/schemas/a.schema.hcl"); !errors.Is(err, ErrSchemaNotFound) {
+		t.Fatalf("expected ErrSchemaNotFound for %s from a remote schema, got %v", abs, err)
+	}
+}
+
+func TestChainResolver(t *testing.T) {
+	failing := ResolverFunc(func(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error) {
+		return nil, "", errors.New("boom")
+	})
+	r := ChainResolver{MapResolver{"a": []byte("from map")}, FileResolver{}}
+
+	if src, _ := readResolved(t, r, "a", ""); src != "from map" {
+		t.Fatalf("expected the first resolver to win, got %q", src)
+	}
+	if src, _ := readResolved(t, r, filepath.Join("testdata", "simple.schema.hcl"), ""); src == "" {
+		t.Fatalf("expected to fall back to the file resolver")
+	}
+	_, _, err := r.Resolve(context.Background(), "missing.schema.hcl", "")
+	if !errors.Is(err, ErrSchemaNotFound) || !strings.Contains(err.Error(), "missing.schema.hcl") {
+		t.Fatalf("expected ErrSchemaNotFound naming the ref, got %v", err)
+	}
+	if _, _, err := (ChainResolver{failing, r}).Resolve(context.Background(), "a", ""); err == nil || err.Error() != "boom" {
+		t.Fatalf("expected errors other than not found to stop the chain, got %v", err)
+	}
+}
@@ -0,0 +1,360 @@
+package hclschema
+
+import (
+	"fmt"
+	"maps"
+	"slices"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// AttributeRules holds constraints between the attributes of a body. Every
+// rule is a group of attribute names.
+//
+// On a body, a group applies as a whole:
+//
+//   - OneOf: at least one of the attributes must be set
+//   - ExactlyOneOf: exactly one of the attributes must be set
+//   - ConflictsWith: at most one of the attributes may be set
+//   - RequiredWith: either all of the attributes are set, or none of them
+//
+// On an attribute, a group is relative to the attribute declaring it: OneOf
+// and ExactlyOneOf include the attribute itself, ConflictsWith lists the
+// attributes that can't be set together with it and RequiredWith the ones
+// that must be set whenever it is. On an attribute pattern, groups are
+// relative to each attribute the pattern is the definition of.
+type AttributeRules struct {
+	OneOf         [][]string
+	ExactlyOneOf  [][]string
+	ConflictsWith [][]string
+	RequiredWith  [][]string
+}
+
+func (r *AttributeRules) isZero() bool {
+	return len(r.OneOf) == 0 && len(r.ExactlyOneOf) == 0 && len(r.ConflictsWith) == 0 && len(r.RequiredWith) == 0
+}
+
+func (r *AttributeRules) append(other AttributeRules) {
+	r.OneOf = append(r.OneOf, other.OneOf...)
+	r.ExactlyOneOf = append(r.ExactlyOneOf, other.ExactlyOneOf...)
+	r.ConflictsWith = append(r.ConflictsWith, other.ConflictsWith...)
+	r.RequiredWith = append(r.RequiredWith, other.RequiredWith...)
+}
+
+// without returns the rules with names left out of every group. Groups left
+// empty are dropped.
+func (r AttributeRules) without(names []string) AttributeRules {
+	filter := func(groups [][]string) [][]string {
+		res := make([][]string, 0, len(groups))
+		for _, group := range groups {
+			group = slices.DeleteFunc(slices.Clone(group), func(name string) bool { return slices.Contains(names, name) })
+			if len(group) > 0 {
+				res = append(res, group)
+			}
+		}
+		return res
+	}
+	return AttributeRules{
+		OneOf:         filter(r.OneOf),
+		ExactlyOneOf:  filter(r.ExactlyOneOf),
+		ConflictsWith: filter(r.ConflictsWith),
+		RequiredWith:  filter(r.RequiredWith),
+	}
+}
+
+// parseAttributeRules reads the rule attributes of a body or attribute block.
+// Each accepts either a list of attribute names, declaring a single group, or
+// a list of such lists.
+func parseAttributeRules(attrs hcl.Attributes, ctx *hcl.EvalContext) (AttributeRules, hcl.Diagnostics) {
+	var rules AttributeRules
+	var diags hcl.Diagnostics
+
+	parse := func(name string) [][]string {
+		a, ok := attrs[name]
+		if !ok {
+			return nil
+		}
+		val, d := a.Expr.Value(ctx)
+		diags = append(diags, d...)
+		if d.HasErrors() {
+			return nil
+		}
+
+		invalid := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("invalid '%s'", name),
+			Detail:   fmt.Sprintf("'%s' must be a list of attribute names, or a list of such lists", name),
+			Subject:  a.Expr.Range().Ptr(),
+		}
+		empty := &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  fmt.Sprintf("invalid '%s'", name),
+			Detail:   fmt.Sprintf("every group of '%s' must name at least one attribute", name),
+			Subject:  a.Expr.Range().Ptr(),
+		}
+		names, ok := stringList(val)
+		if ok {
+			if len(names) == 0 {
+				diags = append(diags, empty)
+				return nil
+			}
+			return [][]string{names}
+		}
+		if val.IsNull() || !val.IsKnown() || !(val.Type().IsListType() || val.Type().IsTupleType()) {
+			diags = append(diags, invalid)
+			return nil
+		}
+		groups := make([][]string, 0, val.LengthInt())
+		for it := val.ElementIterator(); it.Next(); {
+			_, ev := it.Element()
+			names, ok := stringList(ev)
+			if !ok {
+				diags = append(diags, invalid)
+				return nil
+			}
+			if len(names) == 0 {
+				diags = append(diags, empty)
+				return nil
+			}
+			groups = append(groups, names)
+		}
+		return groups
+	}
+
+	rules.OneOf = parse("one_of")
+	rules.ExactlyOneOf = parse("exactly_one_of")
+	rules.ConflictsWith = parse("conflicts_with")
+	rules.RequiredWith = parse("required_with")
+	return rules, diags
+}
+
+// ruleCheck holds the names a rule attribute refers to, to be checked against
+// the attributes of body once the schema is parsed and extended bodies are
+// complete. body is nil until the attribute declaring the rule is added to a
+// body.
+type ruleCheck struct {
+	body  *FullBodySchema
+	attr  *hcl.Attribute
+	names []string
+}
+
+// recordRuleNames records the names of the rule attributes in attrs, parsed
+// into rules, to check them against body.
+func (r *schemaRefs) recordRuleNames(body *FullBodySchema, attrs hcl.Attributes, rules AttributeRules) {
+	for _, rule := range []struct {
+		name   string
+		groups [][]string
+	}{
+		{"one_of", rules.OneOf},
+		{"exactly_one_of", rules.ExactlyOneOf},
+		{"conflicts_with", rules.ConflictsWith},
+		{"required_with", rules.RequiredWith},
+	} {
+		if a, ok := attrs[rule.name]; ok && len(rule.groups) > 0 {
+			r.ruleChecks = append(r.ruleChecks, ruleCheck{body: body, attr: a, names: slices.Concat(rule.groups...)})
+		}
+	}
+}
+
+// claimRuleChecks assigns body to the rule checks recorded since the first
+// start ones without a body, i.e. to the rules of the attributes parsed since.
+func (r *schemaRefs) claimRuleChecks(start int, body *FullBodySchema) {
+	for i := start; i < len(r.ruleChecks); i++ {
+		if r.ruleChecks[i].body == nil {
+			r.ruleChecks[i].body = body
+		}
+	}
+}
+
+// moveRuleChecks reassigns the rule checks of from to to, the body from is
+// copied into.
+func (r *schemaRefs) moveRuleChecks(from, to *FullBodySchema) {
+	for i := range r.ruleChecks {
+		if r.ruleChecks[i].body == from {
+			r.ruleChecks[i].body = to
+		}
+	}
+}
+
+// checkRuleNames reports the names of rules that are neither declared by the
+// body the rule applies to nor matched by one of its attribute patterns,
+// unless the body accepts additional attributes.
+func checkRuleNames(refs *schemaRefs) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for _, c := range refs.ruleChecks {
+		if c.body == nil || c.body.AdditionalAttributes {
+			continue
+		}
+		for _, name := range c.names {
+			if c.body.findAttribute(name) != nil || c.body.findAttributePattern(name) != nil {
+				continue
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "undeclared attribute in rule",
+				Detail:   fmt.Sprintf("'%s' is not declared by the body, nor matched by one of its attribute patterns", name),
+				Subject:  c.attr.Expr.Range().Ptr(),
+			})
+		}
+	}
+	return diags
+}
+
+// stringList returns the elements of val if it is a list of strings.
+func stringList(val cty.Value) ([]string, bool) {
+	if val.IsNull() || !val.IsWhollyKnown() || !(val.Type().IsListType() || val.Type().IsTupleType()) {
+		return nil, false
+	}
+	res := make([]string, 0, val.LengthInt())
+	for it := val.ElementIterator(); it.Next(); {
+		_, ev := it.Element()
+		if ev.IsNull() || ev.Type() != cty.String {
+			return nil, false
+		}
+		res = append(res, ev.AsString())
+	}
+	return res, true
+}
+
+// validateAttributeRules checks the rules declared on fbs and on its
+// attributes against the attributes present in a body. Rules requiring a
+// missing attribute without another attribute to blame are reported at
+// bodyRange.
+func validateAttributeRules(fbs *FullBodySchema, present hcl.Attributes, bodyRange hcl.Range) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if fbs == nil {
+		return diags
+	}
+
+	check := func(rules *AttributeRules, self string) {
+		withSelf := func(group []string) []string {
+			if self == "" {
+				return group
+			}
+			return append([]string{self}, group...)
+		}
+
+		for _, group := range rules.OneOf {
+			group = withSelf(group)
+			if len(setAttributes(group, present)) == 0 {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "missing one of attributes",
+					Detail:   fmt.Sprintf("at least one of %s must be set", quoteNames(group)),
+					Subject:  bodyRange.Ptr(),
+				})
+			}
+		}
+
+		for _, group := range rules.ExactlyOneOf {
+			group = withSelf(group)
+			set := setAttributes(group, present)
+			if len(set) == 0 {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "missing one of attributes",
+					Detail:   fmt.Sprintf("exactly one of %s must be set", quoteNames(group)),
+					Subject:  bodyRange.Ptr(),
+				})
+			}
+			for _, attr := range set[min(1, len(set)):] {
+				diags = append(diags, conflictDiagnostic(attr, set[0], fmt.Sprintf("only one of %s may be set", quoteNames(group))))
+			}
+		}
+
+		for _, group := range rules.ConflictsWith {
+			if self == "" {
+				set := setAttributes(group, present)
+				for _, attr := range set[min(1, len(set)):] {
+					diags = append(diags, conflictDiagnostic(attr, set[0], fmt.Sprintf("only one of %s may be set", quoteNames(group))))
+				}
+				continue
+			}
+			selfAttr, ok := present[self]
+			if !ok {
+				continue
+			}
+			for _, attr := range setAttributes(group, present) {
+				diags = append(diags, conflictDiagnostic(selfAttr, attr, fmt.Sprintf("'%s' can't be set together with '%s'", self, attr.Name)))
+			}
+		}
+
+		for _, group := range rules.RequiredWith {
+			triggers := setAttributes(withSelf(group), present)
+			if self != "" {
+				if _, ok := present[self]; !ok {
+					continue
+				}
+				triggers = triggers[:1]
+			}
+			if len(triggers) == 0 {
+				continue
+			}
+			missing := make([]string, 0)
+			for _, name := range group {
+				if _, ok := present[name]; !ok {
+					missing = append(missing, name)
+				}
+			}
+			if len(missing) == 0 {
+				continue
+			}
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "missing required attribute",
+				Detail:   fmt.Sprintf("%s must be set when '%s' is set", quoteNames(missing), triggers[0].Name),
+				Subject:  triggers[0].Range.Ptr(),
+			})
+		}
+	}
+
+	check(&fbs.AttributeRules, "")
+	for i := range fbs.Attributes {
+		if !fbs.Attributes[i].AttributeRules.isZero() {
+			check(&fbs.Attributes[i].AttributeRules, fbs.Attributes[i].Name)
+		}
+	}
+	// The rules of an attribute pattern apply to every attribute it's the
+	// definition of.
+	for _, name := range slices.Sorted(maps.Keys(present)) {
+		if fbs.findAttribute(name) != nil {
+			continue
+		}
+		if def := fbs.findAttributePattern(name); def != nil && !def.AttributeRules.isZero() {
+			check(&def.AttributeRules, name)
+		}
+	}
+	return diags
+}
+
+// setAttributes returns the attributes of names that are present, in the
+// order of names.
+func setAttributes(names []string, present hcl.Attributes) []*hcl.Attribute {
+	res := make([]*hcl.Attribute, 0, len(names))
+	for _, name := range names {
+		if attr, ok := present[name]; ok {
+			res = append(res, attr)
+		}
+	}
+	return res
+}
+
+func conflictDiagnostic(attr, other *hcl.Attribute, detail string) *hcl.Diagnostic {
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "conflicting attributes",
+		Detail:   detail,
+		Subject:  attr.Range.Ptr(),
+		Extra:    &RelatedRange{Range: other.Range, Message: fmt.Sprintf("'%s' is set here", other.Name)},
+	}
+}
+
+func quoteNames(names []string) string {
+	quoted := make([]string, 0, len(names))
+	for _, name := range names {
+		quoted = append(quoted, "'"+name+"'")
+	}
+	return strings.Join(quoted, ", ")
+}
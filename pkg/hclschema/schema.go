@@ -2,48 +2,303 @@ package hclschema
 
 import (
 	"context"
-	"crypto/sha256"
-	"encoding/hex"
 	"fmt"
-	"io"
-	"net/http"
-	"os"
+	"maps"
 	"path/filepath"
+	"regexp"
+	"slices"
+	"sort"
 	"strings"
-	"time"
 
-	godschema "github.com/avestura/hcl-schema/pkg/hclschema/god_schema"
 	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
 	"github.com/hashicorp/hcl/v2/hclparse"
 	"github.com/zclconf/go-cty/cty"
 )
 
-var httpClient = http.DefaultClient
-
 const SchemaExtension = ".schema.hcl"
 
+// NestingMode describes how repeated blocks of the same type are interpreted.
+type NestingMode string
+
+const (
+	// NestingList allows any number of blocks, in order. It is the default
+	// when a block_header doesn't set `nesting`.
+	NestingList NestingMode = "list"
+	// NestingSet allows any number of blocks whose order is not significant.
+	NestingSet NestingMode = "set"
+	// NestingSingle allows at most one block.
+	NestingSingle NestingMode = "single"
+	// NestingMap keys each block by its labels, so no two blocks may share
+	// the same labels.
+	NestingMap NestingMode = "map"
+)
+
 type BlockHeaderAndBodySchema struct {
 	hcl.BlockHeaderSchema
+	Metadata
+
+	// ID is the `id` of the block_header. For the schema returned by
+	// ParseSchemaFile, it is the `__id` of the schema file.
+	ID string
 
 	BodySchema *FullBodySchema
+
+	// Labels holds the value constraints declared for individual labels.
+	Labels []LabelSchema
+
+	// MatchLabels restricts this definition to blocks whose labels have the
+	// given values, so that e.g. `resource "aws_s3_bucket" "x"` can have a
+	// body schema of its own. Definitions without MatchLabels are used for
+	// blocks that no matching definition claims.
+	MatchLabels []LabelMatch
+
+	// MinItems and MaxItems bound how many blocks matching this header may
+	// appear in a body. A MaxItems of zero means there is no upper bound.
+	MinItems int
+	MaxItems int
+	Nesting  NestingMode
+
+	// UniqueLabels rejects two matching blocks with identical labels.
+	UniqueLabels bool
+
+	Validations []ValidationRule
+}
+
+// LabelSchema constrains the value of the block label called Name.
+type LabelSchema struct {
+	Name string
+	ValueConstraints
+}
+
+// LabelMatch requires the label at Index to equal Value.
+type LabelMatch struct {
+	Index int
+	Value string
+}
+
+// matches reports whether blk satisfies every label match of b.
+func (b *BlockHeaderAndBodySchema) matches(blk *hcl.Block) bool {
+	for _, m := range b.MatchLabels {
+		if m.Index >= len(blk.Labels) || blk.Labels[m.Index] != m.Value {
+			return false
+		}
+	}
+	return true
+}
+
+// RelatedRange is set as the Extra of a diagnostic when the problem also
+// involves a second location, such as the first of two duplicate blocks.
+type RelatedRange struct {
+	Range   hcl.Range
+	Message string
+}
+
+// maxItems returns the effective upper bound of matching blocks, taking the
+// nesting mode into account. Zero means unbounded.
+func (b *BlockHeaderAndBodySchema) maxItems() int {
+	if b.Nesting == NestingSingle && (b.MaxItems == 0 || b.MaxItems > 1) {
+		return 1
+	}
+	return b.MaxItems
+}
+
+// FullAttributeSchema is an hcl.AttributeSchema together with the value
+// constraints declared for it in the schema file. A Type of cty.NilType means
+// the attribute accepts values of any type.
+type FullAttributeSchema struct {
+	hcl.AttributeSchema
+	ValueConstraints
+	AttributeRules
+	Metadata
+
+	Type cty.Type
+
+	// Default is used by Decode when the attribute is omitted. It is
+	// cty.NilVal when the attribute has no default.
+	Default cty.Value
+
+	Validations []ValidationRule
 }
 
 type FullBodySchema struct {
-	Attributes []hcl.AttributeSchema
+	Metadata
+	AttributeRules
+
+	Attributes []FullAttributeSchema
 	Blocks     []BlockHeaderAndBodySchema
+
+	// AttributePatterns define the attributes not declared in Attributes
+	// whose names match a pattern. The first matching pattern applies.
+	AttributePatterns []AttributePattern
+	// AdditionalAttributes accepts undeclared attributes that match no
+	// pattern, without checking their values.
+	AdditionalAttributes bool
+	// AdditionalBlocks accepts blocks of undeclared types, without checking
+	// their content.
+	AdditionalBlocks bool
+}
+
+// AttributePattern defines every attribute whose name matches Pattern. The
+// embedded schema's Name holds the pattern source.
+type AttributePattern struct {
+	Pattern *regexp.Regexp
+	FullAttributeSchema
+}
+
+// isOpen reports whether the body accepts members it doesn't declare.
+func (fbs *FullBodySchema) isOpen() bool {
+	return fbs.AdditionalAttributes || fbs.AdditionalBlocks || len(fbs.AttributePatterns) > 0
+}
+
+// findAttributePattern returns the first attribute pattern matching name.
+func (fbs *FullBodySchema) findAttributePattern(name string) *FullAttributeSchema {
+	for i := range fbs.AttributePatterns {
+		if fbs.AttributePatterns[i].Pattern.MatchString(name) {
+			return &fbs.AttributePatterns[i].FullAttributeSchema
+		}
+	}
+	return nil
+}
+
+// content returns the content of b for the hcl schema bs derived from fbs.
+// Attributes of open bodies that fbs doesn't declare but accepts are returned
+// separately in extra; the ones it doesn't accept are reported as errors, as
+// are blocks of undeclared types unless fbs.AdditionalBlocks is set.
+func (fbs *FullBodySchema) content(b hcl.Body, bs *hcl.BodySchema) (*hcl.BodyContent, hcl.Attributes, hcl.Diagnostics) {
+	if fbs == nil || !fbs.isOpen() {
+		content, diags := b.Content(bs)
+		return content, nil, diags
+	}
+
+	content, remain, diags := b.PartialContent(bs)
+	// Blocks left in remain make JustAttributes complain; they are dealt
+	// with below instead.
+	rest, _ := remain.JustAttributes()
+
+	names := make([]string, 0, len(rest))
+	for name := range rest {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	extra := make(hcl.Attributes)
+	restSchema := &hcl.BodySchema{}
+	for _, name := range names {
+		attr := rest[name]
+		restSchema.Attributes = append(restSchema.Attributes, hcl.AttributeSchema{Name: name})
+		if fbs.AdditionalAttributes || fbs.findAttributePattern(name) != nil {
+			extra[name] = attr
+			continue
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "Unsupported argument",
+			Detail:   fmt.Sprintf("An argument named %q is not expected here.", name),
+			Subject:  attr.NameRange.Ptr(),
+		})
+	}
+
+	if !fbs.AdditionalBlocks {
+		_, d := remain.Content(restSchema)
+		diags = append(diags, d...)
+	}
+
+	return content, extra, diags
 }
 
 func (fbs *FullBodySchema) AsBodySchema() *hcl.BodySchema {
+	originalHclAttributes := make([]hcl.AttributeSchema, 0, len(fbs.Attributes))
+	for _, attr := range fbs.Attributes {
+		originalHclAttributes = append(originalHclAttributes, attr.AttributeSchema)
+	}
 	originalHclBlocks := make([]hcl.BlockHeaderSchema, 0, len(fbs.Blocks))
 	for _, blk := range fbs.Blocks {
 		originalHclBlocks = append(originalHclBlocks, blk.BlockHeaderSchema)
 	}
 	return &hcl.BodySchema{
-		Attributes: fbs.Attributes,
+		Attributes: originalHclAttributes,
 		Blocks:     originalHclBlocks,
 	}
 }
 
+// findBlockDef returns the definition blk is validated against: the first
+// definition whose match_label rules all match blk or, failing that, the first
+// generic definition with the same type and label count.
+func (fbs *FullBodySchema) findBlockDef(blk *hcl.Block) *BlockHeaderAndBodySchema {
+	if fbs == nil {
+		return nil
+	}
+
+	var generic *BlockHeaderAndBodySchema
+	for i := range fbs.Blocks {
+		cand := &fbs.Blocks[i]
+		if cand.Type != blk.Type || len(cand.LabelNames) != len(blk.Labels) {
+			continue
+		}
+		if len(cand.MatchLabels) == 0 {
+			if generic == nil {
+				generic = cand
+			}
+			continue
+		}
+		if cand.matches(blk) {
+			return cand
+		}
+	}
+	return generic
+}
+
+// unmatchedBlockDiagnostic reports blk when every definition with its type and
+// label count has match_label rules, and none of them matches blk. It returns
+// nil if there's no such definition, as for the blocks of open bodies.
+func (fbs *FullBodySchema) unmatchedBlockDiagnostic(blk *hcl.Block) *hcl.Diagnostic {
+	if fbs == nil {
+		return nil
+	}
+	var accepted []string
+	for _, cand := range fbs.Blocks {
+		if cand.Type != blk.Type || len(cand.LabelNames) != len(blk.Labels) {
+			continue
+		}
+		conds := make([]string, 0, len(cand.MatchLabels))
+		for _, m := range cand.MatchLabels {
+			conds = append(conds, fmt.Sprintf("%s = %q", cand.LabelNames[m.Index], m.Value))
+		}
+		accepted = append(accepted, strings.Join(conds, " and "))
+	}
+	if len(accepted) == 0 {
+		return nil
+	}
+
+	labels := make([]string, len(blk.Labels))
+	for i, l := range blk.Labels {
+		labels[i] = fmt.Sprintf("%q", l)
+	}
+	rng := blk.DefRange
+	if len(blk.LabelRanges) > 0 {
+		rng = hcl.RangeBetween(blk.LabelRanges[0], blk.LabelRanges[len(blk.LabelRanges)-1])
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  "no matching block_header",
+		Detail:   fmt.Sprintf("no block_header definition of '%s' matches labels %s; expected %s", blk.Type, strings.Join(labels, ", "), strings.Join(accepted, ", or ")),
+		Subject:  rng.Ptr(),
+	}
+}
+
+func (fbs *FullBodySchema) findAttribute(name string) *FullAttributeSchema {
+	if fbs == nil {
+		return nil
+	}
+	for i := range fbs.Attributes {
+		if fbs.Attributes[i].Name == name {
+			return &fbs.Attributes[i]
+		}
+	}
+	return nil
+}
+
 func ParseSchema(filename string) error {
 	parser := hclparse.NewParser()
 	_, diag := parser.ParseHCLFile(filename)
@@ -62,36 +317,116 @@ func GetInnerDefaultBlocks() []hcl.BlockHeaderSchema {
 }
 
 func ParseSchemaFile(filename string) (*BlockHeaderAndBodySchema, hcl.Diagnostics) {
-	parser := hclparse.NewParser()
-	file, diags := parser.ParseHCLFile(filename)
+	file, diags := hclparse.NewParser().ParseHCLFile(filename)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	fbs, refs, d := parseDetectedSchema(file, filename, nil, defaultSchemaLoader())
+	diags = append(diags, d...)
 	if diags.HasErrors() {
 		return nil, diags
 	}
-	defaultSchema := godschema.GetRootSchema()
 
-	idMap := make(map[string]*FullBodySchema)
-	fbs, d := parseBody(file.Body, defaultSchema, idMap)
+	return &BlockHeaderAndBodySchema{ID: refs.id, BodySchema: fbs}, diags
+}
+
+// ParseSchemaBytes is like ParseSchemaFile, but parses the schema file content
+// src instead of reading a file. filename names the schema in diagnostics, and
+// relative imports are resolved against it. Imports are still loaded with
+// DefaultResolver, from disk or over https; to keep them in memory too, parse
+// the schema with a Validator using a MapResolver.
+func ParseSchemaBytes(src []byte, filename string) (*BlockHeaderAndBodySchema, hcl.Diagnostics) {
+	file, diags := hclparse.NewParser().ParseHCL(src, filename)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	fbs, refs, d := parseDetectedSchema(file, filename, nil, defaultSchemaLoader())
 	diags = append(diags, d...)
 	if diags.HasErrors() {
 		return nil, diags
 	}
 
-	return &BlockHeaderAndBodySchema{BodySchema: fbs}, diags
+	return &BlockHeaderAndBodySchema{ID: refs.id, BodySchema: fbs}, diags
+}
+
+// parseDetectedSchema parses a schema file, loaded from location, written in
+// the dialect its `__schema` selects. It returns its root body along with the
+// ids and definitions it declares or imports. importing holds the locations
+// of the schemas whose imports are being resolved, to detect import cycles.
+func parseDetectedSchema(file *hcl.File, location string, importing []string, loader *schemaLoader) (*FullBodySchema, *schemaRefs, hcl.Diagnostics) {
+	dialect, diags := detectDialect(file.Body)
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	fbs, refs, d := parseSchema(file, location, importing, dialect, loader)
+	return fbs, refs, append(diags, d...)
+}
+
+// parseSchema parses a schema file written in dialect. The file is first
+// validated against the meta-schema of the dialect.
+func parseSchema(file *hcl.File, location string, importing []string, dialect *dialect, loader *schemaLoader) (*FullBodySchema, *schemaRefs, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	if dialect.meta != nil {
+		diags = dialect.meta.ValidateContext(loader.ctx, file.Body)
+		if diags.HasErrors() {
+			return nil, nil, diags
+		}
+	}
+
+	refs := newSchemaRefs(dialect, loader)
+	refs.src = file.Bytes
+	diags = append(diags, parseSchemaID(file.Body, refs)...)
+	diags = append(diags, parseImports(file.Body, location, refs, append(slices.Clip(importing), location))...)
+	if loader.ctx.Err() != nil {
+		// The imports that weren't loaded would make refs to them
+		// unresolved.
+		return nil, nil, diags
+	}
+	diags = append(diags, collectRefs(file.Body, refs)...)
+	diags = append(diags, parseDefinitions(file.Body, refs)...)
+
+	fbs, d := parseBody(file.Body, dialect.root, refs)
+	diags = append(diags, d...)
+	d = applyExtensions(refs)
+	diags = append(diags, d...)
+	if !d.HasErrors() {
+		// Bodies that failed to extend lack the attributes their rules
+		// refer to.
+		diags = append(diags, checkRuleNames(refs)...)
+	}
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+
+	return fbs, refs, diags
 }
 
-func parseBody(body hcl.Body, schema *hcl.BodySchema, idMap map[string]*FullBodySchema) (*FullBodySchema, hcl.Diagnostics) {
+func parseBody(body hcl.Body, schema *hcl.BodySchema, refs *schemaRefs) (*FullBodySchema, hcl.Diagnostics) {
 	content, diags := body.Content(schema)
 	if diags.HasErrors() {
 		return nil, diags
 	}
 
 	fbs := &FullBodySchema{}
-	attrs := make([]hcl.AttributeSchema, 0)
+	attrs := make([]FullAttributeSchema, 0)
 	blocks := make([]BlockHeaderAndBodySchema, 0)
 
-	ctx := &hcl.EvalContext{}
+	ctx := schemaEvalContext
+
+	md, d := parseMetadata(content.Attributes, ctx)
+	diags = append(diags, d...)
+	fbs.Metadata = md
+
+	fbs.AdditionalAttributes, d = evalBoolAttr(content.Attributes, "additional_attributes", ctx)
+	diags = append(diags, d...)
+	fbs.AdditionalBlocks, d = evalBoolAttr(content.Attributes, "additional_blocks", ctx)
+	diags = append(diags, d...)
+	fbs.AttributeRules, d = parseAttributeRules(content.Attributes, ctx)
+	diags = append(diags, d...)
+	refs.recordRuleNames(fbs, content.Attributes, fbs.AttributeRules)
 
-	innerDefault := godschema.GetBodySchema()
+	innerDefault := refs.dialect.body
 
 	for _, block := range content.Blocks {
 		switch block.Type {
@@ -100,21 +435,23 @@ func parseBody(body hcl.Body, schema *hcl.BodySchema, idMap map[string]*FullBody
 			if len(block.Labels) > 0 {
 				name = block.Labels[0]
 			}
-
-			innerSchema := &hcl.BodySchema{Attributes: []hcl.AttributeSchema{{Name: "required"}}}
-			innerContent, d := block.Body.Content(innerSchema)
+			checks := len(refs.ruleChecks)
+			attr, d := parseAttribute(name, block.Body, ctx, refs)
 			diags = append(diags, d...)
+			refs.claimRuleChecks(checks, fbs)
+			attrs = append(attrs, attr)
 
-			required := false
-			if a, ok := innerContent.Attributes["required"]; ok {
-				val, err := a.Expr.Value(ctx)
-				if err != nil {
-					diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to evaluate attribute 'required'", Detail: err.Error(), Subject: &a.Range})
-				} else if val.Type() == cty.Bool {
-					required = val.True()
-				}
+		case "attribute_pattern":
+			re, err := regexp.Compile(block.Labels[0])
+			if err != nil {
+				diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid attribute pattern", Detail: err.Error(), Subject: block.LabelRanges[0].Ptr()})
+				continue
 			}
-			attrs = append(attrs, hcl.AttributeSchema{Name: name, Required: required})
+			checks := len(refs.ruleChecks)
+			attr, d := parseAttribute(block.Labels[0], block.Body, ctx, refs)
+			diags = append(diags, d...)
+			refs.claimRuleChecks(checks, fbs)
+			fbs.AttributePatterns = append(fbs.AttributePatterns, AttributePattern{Pattern: re, FullAttributeSchema: attr})
 
 		case "block_header":
 			typ := ""
@@ -122,7 +459,7 @@ func parseBody(body hcl.Body, schema *hcl.BodySchema, idMap map[string]*FullBody
 				typ = block.Labels[0]
 			}
 
-			innerSchema := godschema.GetBlockHeaderSchema()
+			innerSchema := refs.dialect.blockHeader
 			innerContent, d := block.Body.Content(innerSchema)
 			diags = append(diags, d...)
 
@@ -142,66 +479,209 @@ func parseBody(body hcl.Body, schema *hcl.BodySchema, idMap map[string]*FullBody
 				}
 			}
 
+			var id string
 			var placeholder *FullBodySchema
 			if a, ok := innerContent.Attributes["id"]; ok {
-				txt, terr := extractExprSource(a.Expr)
-				if terr != nil {
-					diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to read 'id' attribute source", Detail: terr.Error()})
-				} else {
-					idVal := strings.Trim(txt, " \t\n\r\"'")
-					if idVal != "" {
-						key := fmt.Sprintf("%s.%s", block.Type, idVal)
-						placeholder = &FullBodySchema{}
-						idMap[key] = placeholder
-					}
+				id, d = parseRefKey(a)
+				diags = append(diags, d...)
+				key := fmt.Sprintf("%s.%s", block.Type, id)
+				if owner, ok := refs.owners[key]; ok && owner == block.DefRange {
+					placeholder = refs.bodies[key]
 				}
 			}
 
 			var nested *FullBodySchema
+			var bodyBlock *hcl.Block
+			labels := make([]LabelSchema, 0)
+			matchLabels := make([]LabelMatch, 0)
 			for _, inner := range innerContent.Blocks {
-				if inner.Type == "body" {
-					nb, d := parseBody(inner.Body, innerDefault, idMap)
+				switch inner.Type {
+				case "body":
+					bodyBlock = inner
+					nb, d := parseBody(inner.Body, innerDefault, refs)
 					diags = append(diags, d...)
-					if placeholder != nil {
-						placeholder.Attributes = nb.Attributes
-						placeholder.Blocks = nb.Blocks
+					if placeholder != nil && nb != nil {
+						*placeholder = *nb
+						refs.moveRuleChecks(nb, placeholder)
 						nested = placeholder
 					} else {
 						nested = nb
 					}
+
+				case "label":
+					labelName := inner.Labels[0]
+					if !slices.Contains(labelNames, labelName) {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "unknown label",
+							Detail:   fmt.Sprintf("label '%s' is not declared in 'label_names' of block_header '%s'", labelName, typ),
+							Subject:  inner.LabelRanges[0].Ptr(),
+						})
+						continue
+					}
+					labelContent, d := inner.Body.Content(refs.dialect.label)
+					diags = append(diags, d...)
+					vc, d := parseValueConstraints(labelContent.Attributes, ctx)
+					diags = append(diags, d...)
+					labels = append(labels, LabelSchema{Name: labelName, ValueConstraints: vc})
+
+				case "match_label":
+					matchContent, d := inner.Body.Content(refs.dialect.matchLabel)
+					diags = append(diags, d...)
+					if d.HasErrors() {
+						continue
+					}
+					index, d := evalCountAttr(matchContent.Attributes, "index", ctx)
+					diags = append(diags, d...)
+					if index == nil {
+						continue
+					}
+					if *index >= len(labelNames) {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "invalid 'index'",
+							Detail:   fmt.Sprintf("block_header '%s' declares %d label(s), so 'index' must be less than %d", typ, len(labelNames), len(labelNames)),
+							Subject:  matchContent.Attributes["index"].Expr.Range().Ptr(),
+						})
+						continue
+					}
+					a := matchContent.Attributes["value"]
+					val, d := a.Expr.Value(ctx)
+					diags = append(diags, d...)
+					if d.HasErrors() {
+						continue
+					}
+					if val.IsNull() || val.Type() != cty.String {
+						diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid 'value'", Detail: "'value' must be a string", Subject: a.Expr.Range().Ptr()})
+						continue
+					}
+					matchLabels = append(matchLabels, LabelMatch{Index: *index, Value: val.AsString()})
 				}
 			}
 
 			if a, ok := innerContent.Attributes["ref"]; ok {
-				txt, terr := extractExprSource(a.Expr)
-				if terr != nil {
-					diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to read 'ref' attribute source", Detail: terr.Error()})
-				} else {
-					refKey := strings.Trim(txt, " \t\n\r\"'")
-					if refKey != "" {
-						if resolved, ok := idMap[refKey]; ok {
-							nested = resolved
-						} else {
-							diags = append(diags, &hcl.Diagnostic{
-								Severity: hcl.DiagError,
-								Summary:  "unresolved ref",
-								Detail:   fmt.Sprintf("ref '%s' not found", refKey),
-								Subject:  &a.Range,
-							})
-						}
+				if bodyBlock != nil {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "conflicting 'ref' and 'body'",
+						Detail:   fmt.Sprintf("block_header '%s' can't declare both 'ref' and a 'body'", typ),
+						Subject:  a.Range.Ptr(),
+						Extra:    &RelatedRange{Range: bodyBlock.DefRange, Message: "body declared here"},
+					})
+				}
+				if e, ok := innerContent.Attributes["extends"]; ok {
+					diags = append(diags, &hcl.Diagnostic{
+						Severity: hcl.DiagError,
+						Summary:  "conflicting 'ref' and 'extends'",
+						Detail:   fmt.Sprintf("block_header '%s' can't declare both 'ref' and 'extends'", typ),
+						Subject:  a.Range.Ptr(),
+						Extra:    &RelatedRange{Range: e.Range, Message: "extends declared here"},
+					})
+				}
+				refKey, d := parseRefKey(a)
+				diags = append(diags, d...)
+				if refKey != "" {
+					if resolved, ok := refs.bodies[refKey]; ok {
+						nested = resolved
+					} else {
+						diags = append(diags, unresolvedRefDiagnostic(refKey, a))
+					}
+				}
+			} else if _, ok := innerContent.Attributes["extends"]; ok {
+				nested, d = parseExtension(innerContent.Attributes, nested, placeholder, bodyBlock, refs)
+				diags = append(diags, d...)
+			} else if a, ok := innerContent.Attributes["remove"]; ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "invalid 'remove'",
+					Detail:   "'remove' can only be used together with 'extends'",
+					Subject:  a.Range.Ptr(),
+				})
+			}
+
+			minItems, d := evalCountAttr(innerContent.Attributes, "min_items", ctx)
+			diags = append(diags, d...)
+			maxItems, d := evalCountAttr(innerContent.Attributes, "max_items", ctx)
+			diags = append(diags, d...)
+			if minItems != nil && maxItems != nil && *maxItems < *minItems {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "invalid 'max_items'",
+					Detail:   fmt.Sprintf("'max_items' (%d) must not be less than 'min_items' (%d)", *maxItems, *minItems),
+					Subject:  innerContent.Attributes["max_items"].Expr.Range().Ptr(),
+				})
+			}
+
+			nesting := NestingList
+			if a, ok := innerContent.Attributes["nesting"]; ok {
+				val, d := a.Expr.Value(ctx)
+				diags = append(diags, d...)
+				if !d.HasErrors() {
+					mode := ""
+					if !val.IsNull() && val.Type() == cty.String {
+						mode = val.AsString()
+					}
+					switch NestingMode(mode) {
+					case NestingList, NestingSet, NestingSingle, NestingMap:
+						nesting = NestingMode(mode)
+					default:
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "invalid 'nesting'",
+							Detail:   "'nesting' must be one of \"single\", \"list\", \"set\" or \"map\"",
+							Subject:  a.Expr.Range().Ptr(),
+						})
+					}
+					if nesting == NestingMap && len(labelNames) == 0 {
+						diags = append(diags, &hcl.Diagnostic{
+							Severity: hcl.DiagError,
+							Summary:  "invalid 'nesting'",
+							Detail:   "blocks with \"map\" nesting must declare at least one label in 'label_names'",
+							Subject:  a.Expr.Range().Ptr(),
+						})
 					}
 				}
 			}
 
+			uniqueLabels := false
+			if a, ok := innerContent.Attributes["unique_labels"]; ok {
+				val, err := a.Expr.Value(ctx)
+				if err != nil {
+					diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to evaluate 'unique_labels'", Detail: err.Error(), Subject: &a.Range})
+				} else if val.Type() == cty.Bool {
+					uniqueLabels = val.True()
+				}
+			}
+
+			md, d := parseMetadata(innerContent.Attributes, ctx)
+			diags = append(diags, d...)
+			validations, d := parseValidationRules(innerContent.Blocks, ctx, refs)
+			diags = append(diags, d...)
+
 			bhs := hcl.BlockHeaderSchema{Type: typ, LabelNames: labelNames}
-			blocks = append(blocks, BlockHeaderAndBodySchema{BlockHeaderSchema: bhs, BodySchema: nested})
+			bhbs := BlockHeaderAndBodySchema{BlockHeaderSchema: bhs, Metadata: md, ID: id, BodySchema: nested, Labels: labels, MatchLabels: matchLabels, Nesting: nesting, UniqueLabels: uniqueLabels, Validations: validations}
+			if minItems != nil {
+				bhbs.MinItems = *minItems
+			}
+			if maxItems != nil {
+				bhbs.MaxItems = *maxItems
+			}
+			blocks = append(blocks, bhbs)
 
 		case "body":
-			nb, d := parseBody(block.Body, innerDefault, idMap)
+			nb, d := parseBody(block.Body, innerDefault, refs)
 			diags = append(diags, d...)
 			if nb != nil {
+				refs.moveRuleChecks(nb, fbs)
 				attrs = append(attrs, nb.Attributes...)
 				blocks = append(blocks, nb.Blocks...)
+				if !nb.Metadata.isZero() {
+					fbs.Metadata = nb.Metadata
+				}
+				fbs.AttributePatterns = append(fbs.AttributePatterns, nb.AttributePatterns...)
+				fbs.AdditionalAttributes = fbs.AdditionalAttributes || nb.AdditionalAttributes
+				fbs.AdditionalBlocks = fbs.AdditionalBlocks || nb.AdditionalBlocks
+				fbs.AttributeRules.append(nb.AttributeRules)
 			}
 		default:
 
@@ -213,6 +693,75 @@ func parseBody(body hcl.Body, schema *hcl.BodySchema, idMap map[string]*FullBody
 	return fbs, diags
 }
 
+// parseAttribute parses the body of an `attribute` block describing the
+// attribute called name.
+func parseAttribute(name string, body hcl.Body, ctx *hcl.EvalContext, refs *schemaRefs) (FullAttributeSchema, hcl.Diagnostics) {
+	innerSchema := refs.dialect.attribute
+	innerContent, diags := body.Content(innerSchema)
+
+	if _, ok := innerContent.Attributes["ref"]; ok {
+		attr, d := resolveAttributeRef(name, innerContent, refs)
+		return attr, append(diags, d...)
+	}
+
+	required := false
+	if a, ok := innerContent.Attributes["required"]; ok {
+		val, err := a.Expr.Value(ctx)
+		if err != nil {
+			diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to evaluate attribute 'required'", Detail: err.Error(), Subject: &a.Range})
+		} else if val.Type() == cty.Bool {
+			required = val.True()
+		}
+	}
+
+	var typ cty.Type
+	if a, ok := innerContent.Attributes["type"]; ok {
+		ty, d := typeexpr.TypeConstraint(a.Expr)
+		diags = append(diags, d...)
+		if !d.HasErrors() {
+			typ = ty
+		}
+	}
+
+	vc, d := parseValueConstraints(innerContent.Attributes, ctx)
+	diags = append(diags, d...)
+	md, d := parseMetadata(innerContent.Attributes, ctx)
+	diags = append(diags, d...)
+	rules, d := parseAttributeRules(innerContent.Attributes, ctx)
+	diags = append(diags, d...)
+	refs.recordRuleNames(nil, innerContent.Attributes, rules)
+	validations, d := parseValidationRules(innerContent.Blocks, ctx, refs)
+	diags = append(diags, d...)
+
+	var def cty.Value
+	if a, ok := innerContent.Attributes["default"]; ok {
+		val, d := a.Expr.Value(ctx)
+		diags = append(diags, d...)
+		if !d.HasErrors() {
+			if typ != cty.NilType && !valueConformsToType(val, typ) {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  "invalid 'default'",
+					Detail:   fmt.Sprintf("default of attribute '%s' must be %s, got %s", name, typeexpr.TypeString(typ), val.Type().FriendlyName()),
+					Subject:  a.Expr.Range().Ptr(),
+				})
+			} else {
+				def = val
+			}
+		}
+	}
+
+	return FullAttributeSchema{
+		AttributeSchema:  hcl.AttributeSchema{Name: name, Required: required},
+		ValueConstraints: vc,
+		AttributeRules:   rules,
+		Metadata:         md,
+		Type:             typ,
+		Default:          def,
+		Validations:      validations,
+	}, diags
+}
+
 func ValidateFileWithSchema(schemaPath, hclPath string) hcl.Diagnostics {
 	var allDiags hcl.Diagnostics
 
@@ -228,239 +777,323 @@ func ValidateFileWithSchema(schemaPath, hclPath string) hcl.Diagnostics {
 		return allDiags
 	}
 
-	parser := hclparse.NewParser()
-	file, d := parser.ParseHCLFile(hclPath)
-	allDiags = append(allDiags, d...)
-	if d.HasErrors() || file == nil {
+	s := newSchema(schemaRes.ID, schemaRes.BodySchema, schemaPath, true)
+	return append(allDiags, s.ValidateFile(hclPath)...)
+}
+
+// ValidateBytes is like ValidateFileWithSchema, but validates the HCL content
+// src against the schema file content schema instead of reading files.
+// filename names src in diagnostics. The schema is named after it, like
+// `service.schema.hcl` for `service.hcl`, and its relative imports are
+// resolved against that name with DefaultResolver, like ParseSchemaBytes.
+// Schema files, named `*.schema.hcl`, are validated against their meta-schema
+// rather than a schema, so filename can't name one; use ParseSchemaBytes to
+// validate them.
+func ValidateBytes(schema, src []byte, filename string) hcl.Diagnostics {
+	if strings.HasSuffix(filename, SchemaExtension) {
+		return hcl.Diagnostics{{
+			Severity: hcl.DiagError,
+			Summary:  "unexpected schema file",
+			Detail:   fmt.Sprintf("'%s' names a schema file, which ValidateBytes can't validate against a schema; use ParseSchemaBytes instead", filename),
+		}}
+	}
+
+	var allDiags hcl.Diagnostics
+	schemaName := strings.TrimSuffix(filename, filepath.Ext(filename)) + SchemaExtension
+	schemaRes, diags := ParseSchemaBytes(schema, schemaName)
+	allDiags = append(allDiags, diags...)
+	if schemaRes == nil || schemaRes.BodySchema == nil {
 		return allDiags
 	}
 
-	findBlockDef := func(fbs *FullBodySchema, blk *hcl.Block) *BlockHeaderAndBodySchema {
-		if fbs == nil {
-			return nil
-		}
+	s := newSchema(schemaRes.ID, schemaRes.BodySchema, schemaName, true)
+	return append(allDiags, s.ValidateBytes(src, filename)...)
+}
 
-		for i := range fbs.Blocks {
-			cand := &fbs.Blocks[i]
-			if cand.Type != blk.Type {
-				continue
-			}
-			if len(cand.LabelNames) == len(blk.Labels) {
-				return cand
-			}
+// validateBody validates b against fbs, and the blocks of b against the bodies
+// of their definitions. The root body of a file may set `__schema` if the
+// schema is linked from files. It stops early, with partial results, once ctx
+// is done.
+func (s *Schema) validateBody(ctx context.Context, b hcl.Body, fbs *FullBodySchema, root bool) hcl.Diagnostics {
+	var res hcl.Diagnostics
+	content, extra, d := fbs.content(b, s.bodySchema(fbs, root))
+	res = append(res, d...)
+
+	if root && s.linked && fbs != nil && fbs.Deprecated {
+		rng := b.MissingItemRange()
+		if a, ok := content.Attributes["__schema"]; ok {
+			rng = a.Range
 		}
-		return nil
+		res = append(res, fbs.deprecationWarning("schema", s.uri, rng))
 	}
 
-	var validate func(b hcl.Body, fbs *FullBodySchema, allowSchemaAttr bool) hcl.Diagnostics
-	validate = func(b hcl.Body, fbs *FullBodySchema, allowSchemaAttr bool) hcl.Diagnostics {
-		var res hcl.Diagnostics
-		var bs *hcl.BodySchema
-		if fbs == nil {
-			bs = &hcl.BodySchema{}
-		} else {
-			bs = fbs.AsBodySchema()
-		}
-		if allowSchemaAttr {
-			bs.Attributes = append(bs.Attributes, hcl.AttributeSchema{Name: "__schema"})
-		}
+	res = append(res, validateAttributes(content.Attributes, extra, fbs)...)
 
-		content, d := b.Content(bs)
-		res = append(res, d...)
+	present := make(hcl.Attributes, len(content.Attributes)+len(extra))
+	maps.Copy(present, content.Attributes)
+	maps.Copy(present, extra)
+	res = append(res, validateAttributeRules(fbs, present, b.MissingItemRange())...)
 
-		for _, blk := range content.Blocks {
-			def := findBlockDef(fbs, blk)
-			if def != nil && def.BodySchema != nil {
-				res = append(res, validate(blk.Body, def.BodySchema, false)...)
+	matched := make(map[*BlockHeaderAndBodySchema][]*hcl.Block)
+	for _, blk := range content.Blocks {
+		if ctx.Err() != nil {
+			return res
+		}
+		def := fbs.findBlockDef(blk)
+		if def == nil {
+			if d := fbs.unmatchedBlockDiagnostic(blk); d != nil {
+				res = append(res, d)
+			}
+			continue
+		}
+		matched[def] = append(matched[def], blk)
+		if w := def.deprecationWarning("block", def.Type, blk.DefRange); w != nil {
+			res = append(res, w)
+		}
+		res = append(res, validateBlockLabels(def, blk)...)
+		if len(def.Validations) > 0 {
+			// Blocks that can't be decoded are reported by the
+			// validation of their body instead.
+			if obj, d := decodeBlock(blk, def); !d.HasErrors() {
+				res = append(res, checkValidationRules(def.Validations, obj, blk.DefRange)...)
 			}
 		}
+		if def.BodySchema != nil {
+			res = append(res, s.validateBody(ctx, blk.Body, def.BodySchema, false)...)
+		}
+	}
+	if ctx.Err() != nil {
+		// The blocks that weren't validated would be reported missing.
 		return res
 	}
 
-	d = validate(file.Body, schemaRes.BodySchema, true)
-	allDiags = append(allDiags, d...)
-	return allDiags
+	if fbs != nil {
+		for i := range fbs.Blocks {
+			def := &fbs.Blocks[i]
+			res = append(res, validateBlockCardinality(def, matched[def], b.MissingItemRange())...)
+		}
+	}
+	return res
 }
 
-// ValidateHCLWithLinkedSchema reads `hclPath`, looks for a linking attribute named
-// `__schema` (string), resolves it relative to `hclPath` when necessary, then
-// validates the HCL file against the referenced schema. Returns diagnostics
-// from parsing or validation.
-func ValidateHCLWithLinkedSchema(hclPath string) hcl.Diagnostics {
-	var allDiags hcl.Diagnostics
-
-	parser := hclparse.NewParser()
-	file, diags := parser.ParseHCLFile(hclPath)
-	allDiags = append(allDiags, diags...)
-	if file == nil || diags.HasErrors() {
-		return allDiags
+// validateAttributes checks the values of attrs against the type and value
+// constraints of their definitions in fbs, and those of extra against the
+// attribute patterns of fbs.
+func validateAttributes(attrs, extra hcl.Attributes, fbs *FullBodySchema) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if fbs == nil {
+		return diags
 	}
 
-	data := file.Bytes
-	if len(data) == 0 {
-		b, rerr := os.ReadFile(hclPath)
-		if rerr != nil {
-			allDiags = append(allDiags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to read file for schema detection", Detail: rerr.Error()})
-			return allDiags
+	for i := range fbs.Attributes {
+		def := &fbs.Attributes[i]
+		if attr, ok := attrs[def.Name]; ok {
+			diags = append(diags, validateAttribute(def, attr)...)
 		}
-		data = b
 	}
 
-	schemaRef, found := extractSchemaRefFromText(string(data))
-	if !found {
-		return allDiags
+	names := make([]string, 0, len(extra))
+	for name := range extra {
+		names = append(names, name)
 	}
-
-	if strings.HasPrefix(schemaRef, "http://") || strings.HasPrefix(schemaRef, "https://") {
-		local, d := fetchRemoteSchema(schemaRef)
-		allDiags = append(allDiags, d...)
-		if d.HasErrors() || local == "" {
-			return allDiags
+	sort.Strings(names)
+	for _, name := range names {
+		if def := fbs.findAttributePattern(name); def != nil {
+			diags = append(diags, validateAttribute(def, extra[name])...)
 		}
-		schemaRef = local
-	} else if !filepath.IsAbs(schemaRef) {
-		schemaRef = filepath.Join(filepath.Dir(hclPath), schemaRef)
 	}
-
-	resDiags := ValidateFileWithSchema(schemaRef, hclPath)
-	allDiags = append(allDiags, resDiags...)
-	return allDiags
+	return diags
 }
 
-func fetchRemoteSchema(url string) (string, hcl.Diagnostics) {
+func validateAttribute(def *FullAttributeSchema, attr *hcl.Attribute) hcl.Diagnostics {
 	var diags hcl.Diagnostics
-	if !strings.HasPrefix(url, "https://") {
-		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "insecure schema URL", Detail: "only https:// URLs are allowed for remote schemas"})
-		return "", diags
+	if w := def.deprecationWarning("attribute", attr.Name, attr.NameRange); w != nil {
+		diags = append(diags, w)
 	}
-
-	cacheDir := filepath.Join(os.TempDir(), "hclschema-cache")
-	if err := os.MkdirAll(cacheDir, 0o755); err != nil {
-		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to create cache dir", Detail: err.Error()})
-		return "", diags
+	// Expressions that need variables or functions can't be checked
+	// statically, so only literal values are checked.
+	val, vd := attr.Expr.Value(nil)
+	if vd.HasErrors() {
+		return diags
 	}
+	if def.Type != cty.NilType && !valueConformsToType(val, def.Type) {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "invalid attribute type",
+			Detail:   fmt.Sprintf("attribute '%s' must be %s, got %s", attr.Name, typeexpr.TypeString(def.Type), val.Type().FriendlyName()),
+			Subject:  attr.Expr.Range().Ptr(),
+		})
+		return diags
+	}
+	if d := def.check(attr.Name, val, attr.Expr.Range()); d.HasErrors() {
+		return append(diags, d...)
+	}
+	return append(diags, checkValidationRules(def.Validations, val, attr.Expr.Range())...)
+}
 
-	h := sha256.Sum256([]byte(url))
-	fname := hex.EncodeToString(h[:]) + ".schema.hcl"
-	full := filepath.Join(cacheDir, fname)
-
-	if fi, err := os.Stat(full); err == nil {
-		if time.Since(fi.ModTime()) < 24*time.Hour {
-			return full, diags
+// validateBlockLabels checks the labels of blk against the label constraints
+// of def.
+func validateBlockLabels(def *BlockHeaderAndBodySchema, blk *hcl.Block) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	for i := range def.Labels {
+		ls := &def.Labels[i]
+		idx := slices.Index(def.LabelNames, ls.Name)
+		if idx < 0 || idx >= len(blk.Labels) {
+			continue
 		}
+		diags = append(diags, ls.check(ls.Name, cty.StringVal(blk.Labels[idx]), blk.LabelRanges[idx])...)
 	}
+	return diags
+}
 
-	ctx, cancel := context.WithTimeout(context.Background(), 15*time.Second)
-	defer cancel()
-	req, err := http.NewRequestWithContext(ctx, "GET", url, nil)
-	if err != nil {
-		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to create request", Detail: err.Error()})
-		return "", diags
-	}
-	resp, err := httpClient.Do(req)
-	if err != nil {
-		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to download schema", Detail: err.Error()})
-		return "", diags
-	}
-	defer resp.Body.Close()
+// validateBlockCardinality checks the blocks matched to def against its item
+// bounds and nesting mode. Missing blocks are reported at bodyRange, extra
+// blocks at the first block beyond the limit.
+func validateBlockCardinality(def *BlockHeaderAndBodySchema, blks []*hcl.Block, bodyRange hcl.Range) hcl.Diagnostics {
+	var diags hcl.Diagnostics
 
-	if resp.StatusCode != http.StatusOK {
-		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to download schema", Detail: resp.Status})
-		return "", diags
+	if len(blks) < def.MinItems {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "too few blocks",
+			Detail:   fmt.Sprintf("at least %d '%s' block(s) required, found %d", def.MinItems, def.Type, len(blks)),
+			Subject:  bodyRange.Ptr(),
+		})
 	}
 
-	const maxSize = 1 << 20
-	r := io.LimitReader(resp.Body, maxSize+1)
-	f, err := os.CreateTemp(cacheDir, "tmp-*.schema.hcl")
-	if err != nil {
-		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to create temp file", Detail: err.Error()})
-		return "", diags
-	}
-	defer func() {
-		f.Close()
-	}()
-	n, err := io.Copy(f, r)
-	if err != nil {
-		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to read schema body", Detail: err.Error()})
-		os.Remove(f.Name())
-		return "", diags
-	}
-	if n > maxSize {
-		diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "schema too large", Detail: "remote schema exceeds maximum allowed size"})
-		os.Remove(f.Name())
-		return "", diags
+	if maxItems := def.maxItems(); maxItems > 0 && len(blks) > maxItems {
+		detail := fmt.Sprintf("at most %d '%s' block(s) allowed, found %d", maxItems, def.Type, len(blks))
+		if def.Nesting == NestingSingle {
+			detail = fmt.Sprintf("only one '%s' block is allowed", def.Type)
+		}
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "too many blocks",
+			Detail:   detail,
+			Subject:  blks[maxItems].DefRange.Ptr(),
+		})
 	}
 
-	if err := os.Rename(f.Name(), full); err != nil {
-		if err2 := copyFile(f.Name(), full); err2 != nil {
-			diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "failed to cache schema", Detail: err2.Error()})
-			os.Remove(f.Name())
-			return "", diags
+	if def.UniqueLabels || def.Nesting == NestingMap {
+		summary := "duplicate block labels"
+		if def.Nesting == NestingMap {
+			summary = "duplicate block key"
+		}
+		seen := make(map[string]*hcl.Block)
+		for _, blk := range blks {
+			key := strings.Join(blk.Labels, "\x00")
+			if first, ok := seen[key]; ok {
+				diags = append(diags, &hcl.Diagnostic{
+					Severity: hcl.DiagError,
+					Summary:  summary,
+					Detail:   fmt.Sprintf("a '%s' block with labels %q was already declared at %s", def.Type, blk.Labels, first.DefRange),
+					Subject:  blk.DefRange.Ptr(),
+					Extra:    &RelatedRange{Range: first.DefRange, Message: "first declared here"},
+				})
+				continue
+			}
+			seen[key] = blk
 		}
-		os.Remove(f.Name())
 	}
 
-	return full, diags
+	return diags
 }
 
-func copyFile(src, dst string) error {
-	in, err := os.Open(src)
-	if err != nil {
-		return err
-	}
-	defer in.Close()
-	out, err := os.Create(dst)
-	if err != nil {
-		return err
+// valueConformsToType reports whether val strictly matches the type constraint
+// ty. Unlike cty's conversion rules, primitive values are never converted, so
+// the number 42 does not satisfy a `string` constraint. Null and unknown values
+// satisfy every constraint.
+func valueConformsToType(val cty.Value, ty cty.Type) bool {
+	if ty == cty.DynamicPseudoType || val.IsNull() || !val.IsKnown() {
+		return true
 	}
-	defer out.Close()
-	if _, err := io.Copy(out, in); err != nil {
-		return err
-	}
-	return out.Sync()
-}
+	vt := val.Type()
 
-func extractExprSource(expr hcl.Expression) (string, error) {
-	r := expr.Range()
-	if r.Filename == "" {
-		return "", fmt.Errorf("expression has no filename range")
-	}
-	data, err := os.ReadFile(r.Filename)
-	if err != nil {
-		return "", err
-	}
-	start := max(int(r.Start.Byte)-1, 0)
-	end := min(int(r.End.Byte), len(data))
-	if start >= end {
-		return "", fmt.Errorf("invalid expression range")
-	}
-	return string(data[start:end]), nil
-}
+	switch {
+	case ty.IsPrimitiveType():
+		return vt.Equals(ty)
 
-func extractSchemaRefFromText(s string) (string, bool) {
-	idx := strings.Index(s, "__schema")
-	if idx == -1 {
-		return "", false
-	}
+	case ty.IsListType(), ty.IsSetType():
+		if !vt.IsListType() && !vt.IsSetType() && !vt.IsTupleType() {
+			return false
+		}
+		for it := val.ElementIterator(); it.Next(); {
+			_, ev := it.Element()
+			if !valueConformsToType(ev, ty.ElementType()) {
+				return false
+			}
+		}
+		return true
 
-	eq := strings.Index(s[idx:], "=")
-	if eq == -1 {
-		return "", false
-	}
-	rest := s[idx+eq+1:]
-	rest = strings.TrimLeft(rest, " \t\n\r")
-	if rest == "" {
-		return "", false
-	}
-	if rest[0] != '"' && rest[0] != '\'' {
-		return "", false
-	}
-	quote := rest[0]
+	case ty.IsMapType():
+		if !vt.IsMapType() && !vt.IsObjectType() {
+			return false
+		}
+		for it := val.ElementIterator(); it.Next(); {
+			_, ev := it.Element()
+			if !valueConformsToType(ev, ty.ElementType()) {
+				return false
+			}
+		}
+		return true
 
-	for i := 1; i < len(rest); i++ {
-		if rest[i] == quote {
-			return rest[1:i], true
+	case ty.IsTupleType():
+		if !vt.IsListType() && !vt.IsTupleType() {
+			return false
 		}
+		etys := ty.TupleElementTypes()
+		if val.LengthInt() != len(etys) {
+			return false
+		}
+		i := 0
+		for it := val.ElementIterator(); it.Next(); i++ {
+			_, ev := it.Element()
+			if !valueConformsToType(ev, etys[i]) {
+				return false
+			}
+		}
+		return true
+
+	case ty.IsObjectType():
+		if !vt.IsMapType() && !vt.IsObjectType() {
+			return false
+		}
+		elems := val.AsValueMap()
+		for name, aty := range ty.AttributeTypes() {
+			ev, ok := elems[name]
+			if !ok {
+				if ty.AttributeOptional(name) {
+					continue
+				}
+				return false
+			}
+			if !valueConformsToType(ev, aty) {
+				return false
+			}
+		}
+		for name := range elems {
+			if !ty.HasAttribute(name) {
+				return false
+			}
+		}
+		return true
 	}
-	return "", false
+
+	return false
+}
+
+// ValidateHCLWithLinkedSchema reads `hclPath`, looks for a linking attribute named
+// `__schema` (string), resolves it relative to `hclPath` when necessary, then
+// validates the HCL file against the referenced schema. Returns diagnostics
+// from parsing or validation.
+func ValidateHCLWithLinkedSchema(hclPath string) hcl.Diagnostics {
+	return ValidateContext(context.Background(), hclPath)
+}
+
+// ValidateContext is like ValidateHCLWithLinkedSchema, but stops loading the
+// schema and its imports, and validating the file, once ctx is done. The
+// diagnostics then end with a "validation canceled" or "validation deadline
+// exceeded" error.
+func ValidateContext(ctx context.Context, hclPath string) hcl.Diagnostics {
+	return NewValidator(ValidatorOptions{}).ValidateFileContext(ctx, hclPath)
 }
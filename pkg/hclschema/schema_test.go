@@ -1,13 +1,19 @@
 package hclschema
 
 import (
+	"context"
+	"errors"
+	"io"
 	"net/http"
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strings"
 	"testing"
 
+	"github.com/hashicorp/hcl/v2"
 	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/zclconf/go-cty/cty"
 )
 
 func TestParseSimpleSchema(t *testing.T) {
@@ -258,27 +264,38 @@ func TestFetchRemoteSchemaHTTPS(t *testing.T) {
 	}))
 	defer srv.Close()
 
-	oldClient := httpClient
-	httpClient = srv.Client()
-	defer func() { httpClient = oldClient }()
-
+	cacheDir := t.TempDir()
+	r := &HTTPSResolver{Client: srv.Client(), CacheDir: cacheDir}
 	url := srv.URL + "/.schema.hcl"
 
-	local, diags := fetchRemoteSchema(url)
-	if diags.HasErrors() {
-		t.Fatalf("fetchRemoteSchema returned diagnostics: %v", diags)
+	rc, uri, err := r.Resolve(context.Background(), url, "")
+	if err != nil {
+		t.Fatalf("Resolve returned an error: %v", err)
 	}
-	if local == "" {
-		t.Fatalf("expected a local cached path, got empty string")
+	defer rc.Close()
+	if uri != url {
+		t.Fatalf("expected canonical URI %s, got %s", url, uri)
 	}
-	if _, err := os.Stat(local); err != nil {
-		t.Fatalf("expected cached file to exist at %s: %v", local, err)
+	cached, _ := filepath.Glob(filepath.Join(cacheDir, "*"+SchemaExtension))
+	if len(cached) != 1 {
+		t.Fatalf("expected one cached schema in %s, got %v", cacheDir, cached)
 	}
 
+	src, err := io.ReadAll(rc)
+	if err != nil {
+		t.Fatalf("failed to read resolved schema: %v", err)
+	}
 	parser := hclparse.NewParser()
-	_, pd := parser.ParseHCLFile(local)
+	_, pd := parser.ParseHCL(src, uri)
 	if pd.HasErrors() {
-		t.Fatalf("failed to parse cached remote schema: %v", pd)
+		t.Fatalf("failed to parse remote schema: %v", pd)
+	}
+}
+
+func TestHTTPSResolverRejectsHTTP(t *testing.T) {
+	_, _, err := (&HTTPSResolver{}).Resolve(context.Background(), "http://example.com/a.schema.hcl", "")
+	if err == nil || errors.Is(err, ErrSchemaNotFound) {
+		t.Fatalf("expected plain http to be rejected, got %v", err)
 	}
 }
 
@@ -340,3 +357,648 @@ func TestParseNormalSchema_RefResolution(t *testing.T) {
 		t.Fatalf("ParseSchemaFile must fail: %v", diags)
 	}
 }
+
+func TestParseTypedSchema(t *testing.T) {
+	path := filepath.Join("testdata", "typed.schema.hcl")
+	res, diags := ParseSchemaFile(path)
+	if diags.HasErrors() {
+		t.Fatalf("diagnostics had errors: %v", diags)
+	}
+
+	expected := map[string]cty.Type{
+		"name":    cty.String,
+		"port":    cty.Number,
+		"enabled": cty.Bool,
+		"hosts":   cty.List(cty.String),
+		"weights": cty.Map(cty.Number),
+		"owner":   cty.Object(map[string]cty.Type{"name": cty.String, "email": cty.String}),
+		"extra":   cty.DynamicPseudoType,
+	}
+	for name, ty := range expected {
+		a := res.BodySchema.findAttribute(name)
+		if a == nil {
+			t.Fatalf("attribute %s not found", name)
+		}
+		if !a.Type.Equals(ty) {
+			t.Fatalf("unexpected type for %s: %#v", name, a.Type)
+		}
+	}
+}
+
+func TestValidateTypedAttributes(t *testing.T) {
+	hclPath := filepath.Join("testdata", "typed.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics for well-typed attributes, got: %v", diags)
+	}
+}
+
+func TestValidateTypedAttributes_Mismatch(t *testing.T) {
+	hclPath := filepath.Join("testdata", "typed_mismatch.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+
+	invalid := make(map[int]bool)
+	for _, d := range diags {
+		if d.Summary == "invalid attribute type" {
+			invalid[d.Subject.Start.Line] = true
+		}
+	}
+	for _, line := range []int{3, 4, 5, 6} {
+		if !invalid[line] {
+			t.Fatalf("expected a type diagnostic on line %d, got: %v", line, diags)
+		}
+	}
+}
+
+func TestValidateValueConstraints(t *testing.T) {
+	hclPath := filepath.Join("testdata", "constrained.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics for constrained values, got: %v", diags)
+	}
+}
+
+func TestValidateValueConstraints_Violations(t *testing.T) {
+	hclPath := filepath.Join("testdata", "constrained_violations.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+
+	summaries := make(map[string]int)
+	for _, d := range diags {
+		summaries[d.Summary] = d.Subject.Start.Line
+	}
+	expected := map[string]int{
+		"value not allowed":            3,
+		"value does not match pattern": 4,
+		"value too long":               4,
+		"value too small":              5,
+		"value too short":              6,
+	}
+	for summary, line := range expected {
+		if got, ok := summaries[summary]; !ok || got != line {
+			t.Fatalf("expected %q on line %d, got: %v", summary, line, diags)
+		}
+	}
+}
+
+func TestValidateBlockCardinality(t *testing.T) {
+	hclPath := filepath.Join("testdata", "cardinality.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics for valid block counts, got: %v", diags)
+	}
+}
+
+func TestValidateBlockCardinality_Violations(t *testing.T) {
+	hclPath := filepath.Join("testdata", "cardinality_violations.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+
+	lines := make(map[int]string)
+	for _, d := range diags {
+		lines[d.Subject.Start.Line] = d.Summary
+	}
+	expected := map[int]string{
+		5:  "too many blocks",
+		8:  "too many blocks",
+		11: "duplicate block key",
+	}
+	for line, summary := range expected {
+		if lines[line] != summary {
+			t.Fatalf("expected %q on line %d, got: %v", summary, line, diags)
+		}
+	}
+}
+
+func TestValidateBlockCardinality_Missing(t *testing.T) {
+	hclPath := filepath.Join("testdata", "cardinality_missing.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if len(diags) != 1 || diags[0].Summary != "too few blocks" {
+		t.Fatalf("expected a single 'too few blocks' diagnostic, got: %v", diags)
+	}
+}
+
+func TestValidateUniqueLabels(t *testing.T) {
+	hclPath := filepath.Join("testdata", "unique_labels.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if len(diags) != 1 {
+		t.Fatalf("expected exactly one diagnostic, got: %v", diags)
+	}
+	d := diags[0]
+	if d.Summary != "duplicate block labels" || d.Subject.Start.Line != 11 {
+		t.Fatalf("expected duplicate labels error on line 11, got: %v", d)
+	}
+	rel, ok := hcl.DiagnosticExtra[*RelatedRange](d)
+	if !ok || rel.Range.Start.Line != 3 {
+		t.Fatalf("expected the first block on line 3 as related range, got: %#v", d.Extra)
+	}
+}
+
+func TestValidateLabelConstraints(t *testing.T) {
+	hclPath := filepath.Join("testdata", "label_constraints.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics for valid labels, got: %v", diags)
+	}
+}
+
+func TestValidateLabelConstraints_Violations(t *testing.T) {
+	hclPath := filepath.Join("testdata", "label_constraints_violations.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if len(diags) != 2 {
+		t.Fatalf("expected two diagnostics, got: %v", diags)
+	}
+
+	if diags[0].Summary != "value not allowed" || diags[0].Subject.Start.Line != 3 || diags[0].Subject.Start.Column != 10 {
+		t.Fatalf("expected 'kind' label to be rejected at its label range, got: %v", diags[0])
+	}
+	if diags[1].Summary != "value does not match pattern" || diags[1].Subject.Start.Line != 4 || diags[1].Subject.Start.Column != 17 {
+		t.Fatalf("expected 'name' label to be rejected at its label range, got: %v", diags[1])
+	}
+}
+
+func TestParseSchema_UnknownLabel(t *testing.T) {
+	path := filepath.Join("testdata", "label_unknown.schema.hcl")
+	_, diags := ParseSchemaFile(path)
+	if !diags.HasErrors() {
+		t.Fatalf("expected an error for a label missing from label_names")
+	}
+}
+
+func TestValidateMatchLabel(t *testing.T) {
+	hclPath := filepath.Join("testdata", "match_label.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics for discriminated blocks, got: %v", diags)
+	}
+}
+
+func TestValidateMatchLabel_UsesMatchingBody(t *testing.T) {
+	hclPath := filepath.Join("testdata", "match_label_mismatch.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if !diags.HasErrors() {
+		t.Fatalf("expected the aws_s3_bucket body schema to reject 'name' and require 'bucket'")
+	}
+}
+
+func TestValidateMatchLabel_NoMatchingDefinition(t *testing.T) {
+	hclPath := filepath.Join("testdata", "match_label_unmatched.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if len(diags) != 1 || diags[0].Summary != "no matching block_header" || diags[0].Subject.Start.Line != 7 {
+		t.Fatalf("expected the unknown_kind resource to be reported, got: %v", diags)
+	}
+	for _, want := range []string{`type = "aws_s3_bucket"`, `type = "aws_instance"`} {
+		if !strings.Contains(diags[0].Detail, want) {
+			t.Fatalf("expected the detail to list %s, got: %s", want, diags[0].Detail)
+		}
+	}
+}
+
+func TestParseSchemaMetadata(t *testing.T) {
+	path := filepath.Join("testdata", "metadata.schema.hcl")
+	res, diags := ParseSchemaFile(path)
+	if diags.HasErrors() {
+		t.Fatalf("diagnostics had errors: %v", diags)
+	}
+
+	if res.BodySchema.Description != "Configuration of a single service." {
+		t.Fatalf("unexpected body description: %q", res.BodySchema.Description)
+	}
+
+	port := res.BodySchema.findAttribute("port")
+	if port.Description != "The port the service listens on." || len(port.Examples) != 2 || port.Deprecated {
+		t.Fatalf("unexpected metadata for port: %#v", port.Metadata)
+	}
+
+	legacy := res.BodySchema.Blocks[0]
+	if !legacy.Deprecated || legacy.DeprecationMessage != "the v1 runtime is going away" {
+		t.Fatalf("unexpected metadata for legacy block: %#v", legacy.Metadata)
+	}
+}
+
+func TestValidateDeprecatedUsage(t *testing.T) {
+	hclPath := filepath.Join("testdata", "metadata.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("deprecations must not be errors, got: %v", diags)
+	}
+	if len(diags) != 2 {
+		t.Fatalf("expected two deprecation warnings, got: %v", diags)
+	}
+	for _, d := range diags {
+		if d.Severity != hcl.DiagWarning {
+			t.Fatalf("expected a warning, got: %v", d)
+		}
+	}
+	if !strings.Contains(diags[0].Detail, "use 'port' instead") {
+		t.Fatalf("expected the deprecation message in the attribute warning, got: %v", diags[0])
+	}
+	if !strings.Contains(diags[1].Detail, "the v1 runtime is going away") {
+		t.Fatalf("expected the deprecation message in the block warning, got: %v", diags[1])
+	}
+}
+
+func TestValidateOpenBodies(t *testing.T) {
+	hclPath := filepath.Join("testdata", "open_body.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics for open bodies, got: %v", diags)
+	}
+}
+
+func TestValidateOpenBodies_Violations(t *testing.T) {
+	hclPath := filepath.Join("testdata", "open_body_violations.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+
+	lines := make(map[int]string)
+	for _, d := range diags {
+		lines[d.Subject.Start.Line] = d.Summary
+	}
+	expected := map[int]string{
+		4:  "invalid attribute type",
+		5:  "Unsupported argument",
+		9:  "Unsupported argument",
+		13: "Unsupported block type",
+	}
+	for line, summary := range expected {
+		if lines[line] != summary {
+			t.Fatalf("expected %q on line %d, got: %v", summary, line, diags)
+		}
+	}
+}
+
+func TestValidateAttributeRules(t *testing.T) {
+	hclPath := filepath.Join("testdata", "attribute_rules.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics, got: %v", diags)
+	}
+}
+
+func TestValidateAttributeRules_Violations(t *testing.T) {
+	hclPath := filepath.Join("testdata", "attribute_rules_violations.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+
+	type found struct {
+		summary string
+		line    int
+	}
+	got := make(map[found]bool)
+	for _, d := range diags {
+		got[found{d.Summary, d.Subject.Start.Line}] = true
+	}
+	expected := []found{
+		{"conflicting attributes", 5},     // cidr and subnet_id both set
+		{"missing one of attributes", 8},  // neither cidr nor subnet_id
+		{"missing one of attributes", 10}, // neither http_port nor https_port
+		{"missing required attribute", 11},
+		{"conflicting attributes", 12},
+		{"conflicting attributes", 17}, // x_team, matched by ^x_, and legacy
+	}
+	for _, e := range expected {
+		if !got[e] {
+			t.Fatalf("expected %q on line %d, got: %v", e.summary, e.line, diags)
+		}
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got: %v", len(expected), diags)
+	}
+}
+
+func TestValidateCustomRules(t *testing.T) {
+	hclPath := filepath.Join("testdata", "validation_rules.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics, got: %v", diags)
+	}
+}
+
+func TestValidateCustomRules_Violations(t *testing.T) {
+	hclPath := filepath.Join("testdata", "validation_rules_violations.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if len(diags) != 2 {
+		t.Fatalf("expected two diagnostics, got: %v", diags)
+	}
+	if diags[0].Detail != "name must be at most 10 lowercase letters" || diags[0].Subject.Start.Line != 3 {
+		t.Fatalf("unexpected attribute validation diagnostic: %v", diags[0])
+	}
+	if diags[1].Detail != "from must be less than to" || diags[1].Subject.Start.Line != 5 {
+		t.Fatalf("unexpected block validation diagnostic: %v", diags[1])
+	}
+}
+
+func TestParseSchema_ValidationWithoutSelf(t *testing.T) {
+	path := filepath.Join("testdata", "validation_no_self.schema.hcl")
+	_, diags := ParseSchemaFile(path)
+	if !diags.HasErrors() {
+		t.Fatalf("expected an error for a condition that doesn't refer to self")
+	}
+}
+
+func TestValidateImportedRef(t *testing.T) {
+	hclPath := filepath.Join("testdata", "import.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics, got: %v", diags)
+	}
+
+	hclPath = filepath.Join("testdata", "import_missing_attr.hcl")
+	diags = ValidateHCLWithLinkedSchema(hclPath)
+	if len(diags) != 1 || diags[0].Summary != "Missing required argument" {
+		t.Fatalf("expected a missing key_file from the imported tls block, got: %v", diags)
+	}
+}
+
+func TestValidateRemoteSchemaRelativeImport(t *testing.T) {
+	srv := httptest.NewTLSServer(http.FileServer(http.Dir("testdata")))
+	defer srv.Close()
+
+	// import.schema.hcl imports ./import_common.schema.hcl, which must be
+	// fetched from the server rather than looked up on disk.
+	v := NewValidator(ValidatorOptions{Resolver: &HTTPSResolver{Client: srv.Client()}})
+	src := "__schema = \"" + srv.URL + "/import.schema.hcl\"\n\nserver \"api\" {\n  tls {\n    cert_file = \"api.crt\"\n  }\n}\n"
+
+	diags := v.ValidateBytes([]byte(src), filepath.Join(t.TempDir(), "import.hcl"))
+	if len(diags) != 1 || diags[0].Summary != "Missing required argument" {
+		t.Fatalf("expected a missing key_file from the remotely imported tls block, got: %v", diags)
+	}
+}
+
+func TestParseSchema_ImportCycle(t *testing.T) {
+	path := filepath.Join("testdata", "import_cycle_a.schema.hcl")
+	_, diags := ParseSchemaFile(path)
+	found := false
+	for _, d := range diags {
+		if d.Summary == "import cycle" {
+			found = true
+		}
+	}
+	if !found {
+		t.Fatalf("expected an import cycle diagnostic, got: %v", diags)
+	}
+}
+
+func TestValidateDefinitions(t *testing.T) {
+	hclPath := filepath.Join("testdata", "definitions.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics, got: %v", diags)
+	}
+}
+
+func TestValidateDefinitions_Violations(t *testing.T) {
+	hclPath := filepath.Join("testdata", "definitions_violations.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+
+	type found struct {
+		summary string
+		line    int
+	}
+	expected := []found{
+		{"value too small", 3},
+		{"value too large", 6},
+		{"Missing required argument", 7},
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got: %v", len(expected), diags)
+	}
+	for i, e := range expected {
+		if diags[i].Summary != e.summary || diags[i].Subject.Start.Line != e.line {
+			t.Fatalf("expected %q on line %d, got: %v", e.summary, e.line, diags[i])
+		}
+	}
+}
+
+func TestParseSchema_UnresolvedAttributeRef(t *testing.T) {
+	path := filepath.Join("testdata", "definitions_unresolved.schema.hcl")
+	_, diags := ParseSchemaFile(path)
+	if len(diags) != 1 || diags[0].Summary != "unresolved ref" {
+		t.Fatalf("expected an unresolved ref diagnostic, got: %v", diags)
+	}
+}
+
+func TestValidateForwardRefs(t *testing.T) {
+	hclPath := filepath.Join("testdata", "ref_forward.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if len(diags) != 1 || diags[0].Summary != "Missing required argument" || diags[0].Subject.Start.Line != 7 {
+		t.Fatalf("expected tls_alias to require cert_file, got: %v", diags)
+	}
+}
+
+func TestParseSchema_InvalidRefs(t *testing.T) {
+	path := filepath.Join("testdata", "ref_invalid.schema.hcl")
+	_, diags := ParseSchemaFile(path)
+
+	type found struct {
+		summary string
+		line    int
+	}
+	got := make(map[found]bool)
+	for _, d := range diags {
+		got[found{d.Summary, d.Subject.Start.Line}] = true
+	}
+	expected := []found{
+		{"duplicate id", 14},
+		{"ref cycle", 7},
+		{"ref cycle", 28},
+		{"conflicting 'ref' and 'body'", 18},
+		{"unresolved ref", 18},
+	}
+	for _, e := range expected {
+		if !got[e] {
+			t.Fatalf("expected %q on line %d, got: %v", e.summary, e.line, diags)
+		}
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got: %v", len(expected), diags)
+	}
+}
+
+func TestParseSchema_InvalidAttributeRules(t *testing.T) {
+	path := filepath.Join("testdata", "attribute_rules_invalid.schema.hcl")
+	_, diags := ParseSchemaFile(path)
+
+	type found struct {
+		summary string
+		line    int
+	}
+	got := make(map[found]bool)
+	for _, d := range diags {
+		got[found{d.Summary, d.Subject.Start.Line}] = true
+	}
+	expected := []found{
+		{"invalid 'one_of'", 15},
+		{"invalid 'exactly_one_of'", 16},
+		{"undeclared attribute in rule", 17}, // hostname
+		{"undeclared attribute in rule", 23}, // socket, from the definition
+	}
+	for _, e := range expected {
+		if !got[e] {
+			t.Fatalf("expected %q on line %d, got: %v", e.summary, e.line, diags)
+		}
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got: %v", len(expected), diags)
+	}
+}
+
+func TestValidateExtends(t *testing.T) {
+	hclPath := filepath.Join("testdata", "extends.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+	if diags.HasErrors() {
+		t.Fatalf("expected no diagnostics, got: %v", diags)
+	}
+}
+
+func TestValidateExtends_Violations(t *testing.T) {
+	hclPath := filepath.Join("testdata", "extends_violations.hcl")
+	diags := ValidateHCLWithLinkedSchema(hclPath)
+
+	type found struct {
+		summary string
+		line    int
+	}
+	got := make(map[found]bool)
+	for _, d := range diags {
+		got[found{d.Summary, d.Subject.Start.Line}] = true
+	}
+	expected := []found{
+		{"Missing required argument", 3}, // replicas is required by prod_service
+		{"value too small", 10},          // min inherited from service
+		{"Unsupported argument", 12},     // debug is removed
+	}
+	for _, e := range expected {
+		if !got[e] {
+			t.Fatalf("expected %q on line %d, got: %v", e.summary, e.line, diags)
+		}
+	}
+	if len(diags) != len(expected) {
+		t.Fatalf("expected %d diagnostics, got: %v", len(expected), diags)
+	}
+}
+
+func TestParseSchema_InvalidExtends(t *testing.T) {
+	path := filepath.Join("testdata", "extends_invalid.schema.hcl")
+	_, diags := ParseSchemaFile(path)
+
+	summaries := make(map[string]bool)
+	for _, d := range diags {
+		summaries[d.Summary] = true
+	}
+	if !summaries["extends cycle"] || !summaries["invalid 'remove'"] {
+		t.Fatalf("expected an extends cycle and an invalid remove, got: %v", diags)
+	}
+}
+
+func TestParseSchema_UnknownDialect(t *testing.T) {
+	path := filepath.Join("testdata", "unknown_dialect.schema.hcl")
+	_, diags := ParseSchemaFile(path)
+	if len(diags) != 1 || diags[0].Summary != "unknown schema dialect" || diags[0].Subject.Start.Line != 1 {
+		t.Fatalf("expected an unknown dialect diagnostic, got: %v", diags)
+	}
+}
+
+func TestValidateSchemaFileAgainstMetaSchema(t *testing.T) {
+	path := filepath.Join("testdata", "meta_violations.schema.hcl")
+	diags := ValidateHCLWithLinkedSchema(path)
+	if len(diags) != 2 {
+		t.Fatalf("expected two diagnostics, got: %v", diags)
+	}
+	if diags[0].Summary != "Unsupported argument" || diags[0].Subject.Start.Line != 6 {
+		t.Fatalf("expected the misspelled keyword to be rejected, got: %v", diags[0])
+	}
+	if diags[1].Summary != "Missing label_name for label" || diags[1].Subject.Start.Line != 9 {
+		t.Fatalf("expected the label block to require a name, got: %v", diags[1])
+	}
+}
+
+const inMemorySchema = `__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "memory://service"
+
+body {
+    attribute "name" {
+        required = true
+        type     = string
+    }
+    block_header "listener" {
+        id          = "listener"
+        label_names = ["name"]
+        body {
+            attribute "port" {
+                type = number
+                min  = 1
+            }
+        }
+    }
+    block_header "admin_listener" {
+        label_names = ["name"]
+        ref         = block_header.listener
+    }
+}
+`
+
+func TestParseSchemaBytes(t *testing.T) {
+	res, diags := ParseSchemaBytes([]byte(inMemorySchema), "does-not-exist/service.schema.hcl")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	if res.ID != "memory://service" {
+		t.Fatalf("expected the ID of the schema, got %q", res.ID)
+	}
+	if len(res.BodySchema.Blocks) != 2 || res.BodySchema.Blocks[1].BodySchema != res.BodySchema.Blocks[0].BodySchema {
+		t.Fatalf("expected admin_listener to share the body of listener")
+	}
+
+	_, diags = ParseSchemaBytes([]byte(strings.Replace(inMemorySchema, "block_header.listener", "block_header.missing", 1)), "service.schema.hcl")
+	if !diags.HasErrors() || diags[0].Summary != "unresolved ref" || diags[0].Subject.Filename != "service.schema.hcl" {
+		t.Fatalf("expected an unresolved ref in service.schema.hcl, got: %v", diags)
+	}
+}
+
+func TestValidateBytes(t *testing.T) {
+	valid := "name = \"api\"\n\nadmin_listener \"internal\" {\n    port = 9000\n}\n"
+	if diags := ValidateBytes([]byte(inMemorySchema), []byte(valid), "does-not-exist/service.hcl"); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+
+	invalid := "listener \"public\" {\n    port = 0\n}\n"
+	diags := ValidateBytes([]byte(inMemorySchema), []byte(invalid), "service.hcl")
+	summaries := make(map[string]string)
+	for _, d := range diags {
+		summaries[d.Summary] = d.Subject.Filename
+	}
+	if summaries["Missing required argument"] != "service.hcl" || summaries["value too small"] != "service.hcl" {
+		t.Fatalf("expected a missing name and a port too small in service.hcl, got: %v", diags)
+	}
+}
+
+func TestValidateBytes_SchemaFile(t *testing.T) {
+	diags := ValidateBytes([]byte(inMemorySchema), []byte(inMemorySchema), "service.schema.hcl")
+	if len(diags) != 1 || diags[0].Summary != "unexpected schema file" {
+		t.Fatalf("expected the schema file name to be rejected, got: %v", diags)
+	}
+}
+
+func TestParseSchemaBytes_RefForms(t *testing.T) {
+	for _, ref := range []string{`block_header.listener`, `block_header["listener"]`, `"block_header.listener"`} {
+		src := strings.Replace(inMemorySchema, "block_header.listener", ref, 1)
+		res, diags := ParseSchemaBytes([]byte(src), "does-not-exist/service.schema.hcl")
+		if diags.HasErrors() {
+			t.Fatalf("ref = %s: unexpected errors: %v", ref, diags)
+		}
+		if res.BodySchema.Blocks[1].BodySchema != res.BodySchema.Blocks[0].BodySchema {
+			t.Fatalf("ref = %s: expected admin_listener to share the body of listener", ref)
+		}
+	}
+
+	src := strings.Replace(inMemorySchema, "block_header.listener", "block_header[0]", 1)
+	_, diags := ParseSchemaBytes([]byte(src), "service.schema.hcl")
+	if !diags.HasErrors() || diags[0].Summary != "invalid 'ref'" {
+		t.Fatalf("expected an invalid ref, got: %v", diags)
+	}
+}
+
+func TestValidateBytes_InvalidSchema(t *testing.T) {
+	diags := ValidateBytes([]byte("body {\n"), []byte("name = \"api\"\n"), "service.hcl")
+	if !diags.HasErrors() || diags[0].Subject.Filename != "service.schema.hcl" {
+		t.Fatalf("expected the schema to be reported as service.schema.hcl, got: %v", diags)
+	}
+}
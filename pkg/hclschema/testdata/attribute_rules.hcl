@@ -0,0 +1,19 @@
+__schema = "attribute_rules.schema.hcl"
+
+network "a" {
+  cidr = "10.0.0.0/16"
+}
+
+network "b" {
+  subnet_id = "subnet-123"
+}
+
+server {
+  https_port = 443
+  tls_cert   = "cert.pem"
+  tls_key    = "key.pem"
+}
+
+extensions {
+  x_team = "core"
+}
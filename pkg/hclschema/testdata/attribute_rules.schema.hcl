@@ -0,0 +1,43 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://attribute_rules"
+
+body {
+    block_header "network" {
+        label_names = ["name"]
+
+        body {
+            exactly_one_of = ["cidr", "subnet_id"]
+
+            attribute "cidr" {}
+            attribute "subnet_id" {}
+        }
+    }
+
+    block_header "server" {
+        body {
+            one_of = ["http_port", "https_port"]
+
+            attribute "http_port" {}
+            attribute "https_port" {}
+
+            attribute "tls_cert" {
+                required_with = ["tls_key"]
+            }
+            attribute "tls_key" {}
+
+            attribute "debug" {
+                conflicts_with = ["tls_cert"]
+            }
+        }
+    }
+
+    block_header "extensions" {
+        body {
+            attribute "legacy" {}
+
+            attribute_pattern "^x_" {
+                conflicts_with = ["legacy"]
+            }
+        }
+    }
+}
@@ -0,0 +1,46 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://attribute_rules_invalid"
+
+definitions {
+    attribute "port" {
+        conflicts_with = ["socket"]
+    }
+}
+
+body {
+    block_header "server" {
+        id = "server"
+
+        body {
+            one_of         = []
+            exactly_one_of = [[]]
+            conflicts_with = ["host", "hostname"]
+
+            attribute "host" {
+                required_with = ["port", "x_port"]
+            }
+            attribute "port" {
+                ref = definitions.attribute.port
+            }
+
+            attribute_pattern "^x_" {}
+        }
+    }
+
+    block_header "tls_server" {
+        extends = block_header.server
+
+        body {
+            required_with = ["host", "cert_file"]
+
+            attribute "cert_file" {}
+        }
+    }
+
+    block_header "open" {
+        body {
+            additional_attributes = true
+            one_of                = ["anything"]
+        }
+    }
+}
@@ -0,0 +1,18 @@
+__schema = "attribute_rules.schema.hcl"
+
+network "both" {
+  cidr      = "10.0.0.0/16"
+  subnet_id = "subnet-123"
+}
+
+network "none" {}
+
+server {
+  tls_cert = "cert.pem"
+  debug    = true
+}
+
+extensions {
+  legacy = true
+  x_team = "core"
+}
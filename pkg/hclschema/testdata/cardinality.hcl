@@ -0,0 +1,16 @@
+__schema = "cardinality.schema.hcl"
+
+listener "http" {
+  port = 80
+}
+
+listener "https" {
+  port = 443
+}
+
+settings {
+  debug = true
+}
+
+route "/" {}
+route "/health" {}
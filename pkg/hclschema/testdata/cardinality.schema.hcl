@@ -0,0 +1,27 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://cardinality"
+
+body {
+    block_header "listener" {
+        label_names = ["name"]
+        min_items   = 1
+        max_items   = 2
+
+        body {
+            attribute "port" {}
+        }
+    }
+
+    block_header "settings" {
+        nesting = "single"
+
+        body {
+            attribute "debug" {}
+        }
+    }
+
+    block_header "route" {
+        label_names = ["path"]
+        nesting     = "map"
+    }
+}
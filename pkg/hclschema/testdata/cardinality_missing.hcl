@@ -0,0 +1,3 @@
+__schema = "cardinality.schema.hcl"
+
+settings {}
@@ -0,0 +1,11 @@
+__schema = "cardinality.schema.hcl"
+
+listener "http" {}
+listener "https" {}
+listener "admin" {}
+
+settings {}
+settings {}
+
+route "/" {}
+route "/" {}
@@ -0,0 +1,6 @@
+__schema = "constrained.schema.hcl"
+
+env      = "prod"
+name     = "payments-api"
+replicas = 3
+zones    = ["eu-west-1a"]
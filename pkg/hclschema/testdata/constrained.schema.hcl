@@ -0,0 +1,24 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://constrained"
+
+body {
+    attribute "env" {
+        type           = string
+        allowed_values = ["dev", "prod"]
+    }
+    attribute "name" {
+        type       = string
+        pattern    = "^[a-z0-9-]+$"
+        min_length = 3
+        max_length = 16
+    }
+    attribute "replicas" {
+        type = number
+        min  = 1
+        max  = 10
+    }
+    attribute "zones" {
+        type       = list(string)
+        min_length = 1
+    }
+}
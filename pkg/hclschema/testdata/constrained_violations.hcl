@@ -0,0 +1,6 @@
+__schema = "constrained.schema.hcl"
+
+env      = "staging"
+name     = "Payments_API_Service_v2"
+replicas = 0
+zones    = []
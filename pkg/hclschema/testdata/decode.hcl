@@ -0,0 +1,14 @@
+__schema = "decode.schema.hcl"
+
+name  = "api"
+hosts = ["a", "b"]
+
+listener "http" {}
+
+listener "https" {
+  tls = true
+}
+
+route "/" {
+  target = "home"
+}
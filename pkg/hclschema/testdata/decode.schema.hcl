@@ -0,0 +1,46 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://decode"
+
+body {
+    attribute "name" {
+        required = true
+        type     = string
+    }
+    attribute "port" {
+        type    = number
+        default = 8080
+    }
+    attribute "hosts" {
+        type = list(string)
+    }
+
+    block_header "listener" {
+        label_names = ["name"]
+
+        body {
+            attribute "tls" {
+                default = false
+            }
+        }
+    }
+
+    block_header "settings" {
+        nesting = "single"
+
+        body {
+            attribute "debug" {
+                type    = bool
+                default = false
+            }
+        }
+    }
+
+    block_header "route" {
+        label_names = ["path"]
+        nesting     = "map"
+
+        body {
+            attribute "target" {}
+        }
+    }
+}
@@ -0,0 +1,10 @@
+__schema = "definitions.schema.hcl"
+
+port = 8080
+
+upstream "api" {
+    admin_port = 9090
+    retry {
+        attempts = 3
+    }
+}
@@ -0,0 +1,36 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id = "local://definitions"
+
+body {
+    attribute "port" {
+        ref = definitions.attribute.port
+    }
+    block_header "upstream" {
+        label_names = ["name"]
+        ref = definitions.body.upstream
+    }
+}
+
+definitions {
+    body "upstream" {
+        attribute "admin_port" {
+            ref = definitions.attribute.port
+        }
+        block_header "retry" {
+            ref = definitions.body.retry
+        }
+    }
+
+    body "retry" {
+        attribute "attempts" {
+            required = true
+            type = number
+        }
+    }
+
+    attribute "port" {
+        type = number
+        min = 1
+        max = 65535
+    }
+}
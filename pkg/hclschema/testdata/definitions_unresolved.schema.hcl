@@ -0,0 +1,8 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id = "local://definitions_unresolved"
+
+body {
+    attribute "port" {
+        ref = definitions.attribute.missing
+    }
+}
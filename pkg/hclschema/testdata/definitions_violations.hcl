@@ -0,0 +1,8 @@
+__schema = "definitions.schema.hcl"
+
+port = 0
+
+upstream "api" {
+    admin_port = 70000
+    retry {}
+}
@@ -0,0 +1,12 @@
+__schema = "extends.schema.hcl"
+
+service "worker" {
+    image = "worker:1"
+    debug = true
+}
+
+prod_service "api" {
+    image    = "api:1"
+    replicas = 3
+    owner    = "platform"
+}
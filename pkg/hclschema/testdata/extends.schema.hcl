@@ -0,0 +1,39 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id = "local://extends"
+
+body {
+    block_header "prod_service" {
+        label_names = ["name"]
+        extends = block_header.service
+        remove = ["debug"]
+
+        body {
+            attribute "replicas" {
+                required = true
+            }
+            attribute "owner" {
+                required = true
+                type = string
+            }
+        }
+    }
+
+    block_header "service" {
+        id = "service"
+        label_names = ["name"]
+
+        body {
+            attribute "image" {
+                required = true
+                type = string
+            }
+            attribute "replicas" {
+                type = number
+                min = 1
+            }
+            attribute "debug" {
+                type = bool
+            }
+        }
+    }
+}
@@ -0,0 +1,17 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id = "local://extends_invalid"
+
+body {
+    block_header "a" {
+        id = "a"
+        extends = block_header.b
+    }
+    block_header "b" {
+        id = "b"
+        extends = block_header.a
+    }
+    block_header "c" {
+        extends = block_header.b
+        remove = ["missing"]
+    }
+}
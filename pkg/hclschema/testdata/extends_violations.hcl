@@ -0,0 +1,13 @@
+__schema = "extends.schema.hcl"
+
+prod_service "api" {
+    image = "api:1"
+    owner = "platform"
+}
+
+prod_service "web" {
+    image    = "web:1"
+    replicas = 0
+    owner    = "platform"
+    debug    = true
+}
@@ -0,0 +1,29 @@
+__id = "local://format"
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+
+body {
+    block_header "zone" {
+        body {
+          attribute "name" {}
+        }
+        // The zone label.
+        label_names = [
+          "name",
+        ]
+        min_items = 1
+    }
+    attribute "port" {
+        max = 65535
+        type = number   # inclusive
+        min = 1
+        required = false
+    }
+    additional_blocks = false
+    // Any name is accepted.
+    attribute "name" { required = true }
+    attribute "x" {}
+    attribute "a" {
+        description = "first"
+        required = true
+    }
+}
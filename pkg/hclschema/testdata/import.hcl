@@ -0,0 +1,9 @@
+__schema = "import.schema.hcl"
+
+server "api" {
+    port = 443
+    tls {
+        cert_file = "api.crt"
+        key_file  = "api.key"
+    }
+}
@@ -0,0 +1,20 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id = "local://import"
+
+import "common" {
+    source = "./import_common.schema.hcl"
+}
+
+body {
+    block_header "server" {
+        label_names = ["name"]
+        body {
+            attribute "port" {
+                type = number
+            }
+            block_header "tls" {
+                ref = common.block_header.tls
+            }
+        }
+    }
+}
@@ -0,0 +1,18 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id = "local://import_common"
+
+body {
+    block_header "tls" {
+        id = "tls"
+        body {
+            attribute "cert_file" {
+                required = true
+                type = string
+            }
+            attribute "key_file" {
+                required = true
+                type = string
+            }
+        }
+    }
+}
@@ -0,0 +1,6 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id = "local://import_cycle_b"
+
+import "a" {
+    source = "./import_cycle_a.schema.hcl"
+}
@@ -0,0 +1,7 @@
+__schema = "import.schema.hcl"
+
+server "api" {
+    tls {
+        cert_file = "api.crt"
+    }
+}
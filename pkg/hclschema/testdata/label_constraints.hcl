@@ -0,0 +1,4 @@
+__schema = "label_constraints.schema.hcl"
+
+listener "http" "public_api" {}
+listener "tcp" "internal" {}
@@ -0,0 +1,16 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://label_constraints"
+
+body {
+    block_header "listener" {
+        label_names = ["kind", "name"]
+
+        label "kind" {
+            allowed_values = ["http", "tcp"]
+        }
+
+        label "name" {
+            pattern = "^[a-z_]+$"
+        }
+    }
+}
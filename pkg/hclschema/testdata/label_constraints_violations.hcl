@@ -0,0 +1,4 @@
+__schema = "label_constraints.schema.hcl"
+
+listener "udp" "public_api" {}
+listener "http" "Public-API" {}
@@ -0,0 +1,12 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://label_unknown"
+
+body {
+    block_header "listener" {
+        label_names = ["name"]
+
+        label "kind" {
+            allowed_values = ["http", "tcp"]
+        }
+    }
+}
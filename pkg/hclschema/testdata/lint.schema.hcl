@@ -0,0 +1,41 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://lint"
+
+body {
+    attribute "name" {
+        required = "yes"
+    }
+    attribute "name" {}
+
+    block_header "foo" {
+        label_names = ["o"]
+    }
+    block_header "foo" {
+        label_names = ["o2"]
+    }
+    block_header "foo" {
+        label_names = [""]
+        id          = "unused"
+        match_label {
+            index = 0
+            value = "x"
+        }
+    }
+
+    block_header "service" {
+        id = "service"
+        body {
+            attribute "port" {}
+        }
+    }
+    block_header "worker" {
+        ref = block_header.service
+    }
+}
+
+body {
+    attribute "name" {}
+    block_header "worker" {
+        ref = block_header.service
+    }
+}
@@ -0,0 +1,22 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://lint_clean"
+
+body {
+    attribute "name" {
+        required = true
+    }
+
+    block_header "foo" {
+        label_names = ["o"]
+    }
+    block_header "foo" {
+        label_names = ["o"]
+        match_label {
+            index = 0
+            value = "x"
+        }
+    }
+    block_header "foo" {
+        label_names = ["o", "p"]
+    }
+}
@@ -0,0 +1,9 @@
+__schema = "match_label.schema.hcl"
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "my-logs"
+}
+
+resource "aws_instance" "web" {
+  name = "web"
+}
@@ -0,0 +1,27 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://match_label"
+
+body {
+    block_header "resource" {
+        label_names = ["type", "name"]
+
+        body {
+            attribute "name" {}
+        }
+    }
+
+    block_header "resource" {
+        label_names = ["type", "name"]
+
+        match_label {
+            index = 0
+            value = "aws_s3_bucket"
+        }
+
+        body {
+            attribute "bucket" {
+                required = true
+            }
+        }
+    }
+}
@@ -0,0 +1,5 @@
+__schema = "match_label.schema.hcl"
+
+resource "aws_s3_bucket" "logs" {
+  name = "my-logs"
+}
@@ -0,0 +1,34 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://match_label_only"
+
+body {
+    block_header "resource" {
+        label_names = ["type", "name"]
+
+        match_label {
+            index = 0
+            value = "aws_s3_bucket"
+        }
+
+        body {
+            attribute "bucket" {
+                required = true
+            }
+        }
+    }
+
+    block_header "resource" {
+        label_names = ["type", "name"]
+
+        match_label {
+            index = 0
+            value = "aws_instance"
+        }
+
+        body {
+            attribute "ami" {
+                required = true
+            }
+        }
+    }
+}
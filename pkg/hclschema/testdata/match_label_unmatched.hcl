@@ -0,0 +1,9 @@
+__schema = "match_label_only.schema.hcl"
+
+resource "aws_s3_bucket" "logs" {
+  bucket = "my-logs"
+}
+
+resource "unknown_kind" "x" {
+  anything = 1
+}
@@ -0,0 +1,11 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id = "local://meta_violations"
+
+body {
+    attribute "name" {
+        requird = true
+    }
+    block_header "tag" {
+        label {}
+    }
+}
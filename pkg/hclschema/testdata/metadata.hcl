@@ -0,0 +1,5 @@
+__schema = "metadata.schema.hcl"
+
+listen_port = 8080
+
+legacy {}
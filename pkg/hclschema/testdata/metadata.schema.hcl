@@ -0,0 +1,21 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://metadata"
+
+body {
+    description = "Configuration of a single service."
+
+    attribute "port" {
+        description = "The port the service listens on."
+        examples    = [8080, 9090]
+    }
+
+    attribute "listen_port" {
+        deprecated          = true
+        deprecation_message = "use 'port' instead"
+    }
+
+    block_header "legacy" {
+        description         = "Settings of the v1 runtime."
+        deprecation_message = "the v1 runtime is going away"
+    }
+}
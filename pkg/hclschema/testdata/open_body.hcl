@@ -0,0 +1,19 @@
+__schema = "open_body.schema.hcl"
+
+labels {
+  team = "payments"
+  tier = 1
+}
+
+env {
+  PATH    = "/usr/bin"
+  X_DEBUG = "1"
+}
+
+plugin "metrics" {
+  enabled = true
+
+  exporter "prometheus" {
+    port = 9090
+  }
+}
@@ -0,0 +1,30 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://open_body"
+
+body {
+    block_header "labels" {
+        body {
+            additional_attributes = true
+        }
+    }
+
+    block_header "env" {
+        body {
+            attribute "PATH" {}
+
+            attribute_pattern "^X_" {
+                type = string
+            }
+        }
+    }
+
+    block_header "plugin" {
+        label_names = ["name"]
+
+        body {
+            additional_blocks = true
+
+            attribute "enabled" {}
+        }
+    }
+}
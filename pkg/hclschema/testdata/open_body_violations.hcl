@@ -0,0 +1,14 @@
+__schema = "open_body.schema.hcl"
+
+env {
+  X_DEBUG = 1
+  DEBUG   = "1"
+}
+
+plugin "metrics" {
+  region = "eu"
+}
+
+labels {
+  settings {}
+}
@@ -0,0 +1,7 @@
+__schema = "ref_forward.schema.hcl"
+
+proxy {
+    cert_file = "proxy.crt"
+}
+
+tls_alias {}
@@ -0,0 +1,20 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id = "local://ref_forward"
+
+body {
+    block_header "proxy" {
+        ref = block_header.tls_alias
+    }
+    block_header "tls_alias" {
+        id = "tls_alias"
+        ref = block_header.tls
+    }
+    block_header "tls" {
+        id = "tls"
+        body {
+            attribute "cert_file" {
+                required = true
+            }
+        }
+    }
+}
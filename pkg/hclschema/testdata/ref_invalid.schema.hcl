@@ -0,0 +1,30 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id = "local://ref_invalid"
+
+body {
+    block_header "a" {
+        id = "a"
+        ref = block_header.b
+    }
+    block_header "b" {
+        id = "b"
+        ref = block_header.a
+    }
+    block_header "c" {
+        id = "a"
+        body {}
+    }
+    block_header "d" {
+        ref = block_header.c
+        body {}
+    }
+    attribute "port" {
+        ref = definitions.attribute.port
+    }
+}
+
+definitions {
+    attribute "port" {
+        ref = definitions.attribute.port
+    }
+}
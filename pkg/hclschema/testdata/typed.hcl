@@ -0,0 +1,15 @@
+__schema = "typed.schema.hcl"
+
+name    = "api"
+port    = 8080
+enabled = true
+hosts   = ["a.example.com", "b.example.com"]
+weights = {
+  a = 1
+  b = 2.5
+}
+owner = {
+  name  = "ops"
+  email = "ops@example.com"
+}
+extra = [1, "two", false]
@@ -0,0 +1,29 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://typed"
+
+body {
+    attribute "name" {
+        type = string
+    }
+    attribute "port" {
+        type = number
+    }
+    attribute "enabled" {
+        type = bool
+    }
+    attribute "hosts" {
+        type = list(string)
+    }
+    attribute "weights" {
+        type = map(number)
+    }
+    attribute "owner" {
+        type = object({
+            name  = string
+            email = string
+        })
+    }
+    attribute "extra" {
+        type = any
+    }
+}
@@ -0,0 +1,8 @@
+__schema = "typed.schema.hcl"
+
+name  = 42
+port  = "8080"
+hosts = ["a.example.com", 1]
+owner = {
+  name = "ops"
+}
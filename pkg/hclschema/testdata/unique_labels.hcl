@@ -0,0 +1,13 @@
+__schema = "unique_labels.schema.hcl"
+
+tag "a" {
+  x = 1
+}
+
+tag "b" {
+  x = 2
+}
+
+tag "a" {
+  x = 3
+}
@@ -0,0 +1,13 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://unique_labels"
+
+body {
+    block_header "tag" {
+        label_names   = ["name"]
+        unique_labels = true
+
+        body {
+            attribute "x" {}
+        }
+    }
+}
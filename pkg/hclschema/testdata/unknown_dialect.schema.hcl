@@ -0,0 +1,6 @@
+__schema = "https://example.com/schema/draft/1999-01/.schema.hcl"
+__id = "local://unknown_dialect"
+
+body {
+    attribute "name" {}
+}
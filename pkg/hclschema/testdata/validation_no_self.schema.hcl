@@ -0,0 +1,11 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://validation_no_self"
+
+body {
+    attribute "name" {
+        validation {
+            condition     = true
+            error_message = "never fails"
+        }
+    }
+}
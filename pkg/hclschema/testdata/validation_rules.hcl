@@ -0,0 +1,14 @@
+__schema = "validation_rules.schema.hcl"
+
+name = "payments"
+tier = "pro"
+
+range {
+  from = 1
+  to   = 5
+}
+
+# The condition reads the unset 'to', so it is skipped.
+range {
+  from = 1
+}
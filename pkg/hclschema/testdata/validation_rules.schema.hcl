@@ -0,0 +1,29 @@
+__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "local://validation_rules"
+
+body {
+    attribute "name" {
+        type = string
+
+        validation {
+            condition     = length(self) <= 10 && can(regex("^[a-z]+$", self))
+            error_message = "name must be at most 10 lowercase letters"
+        }
+    }
+
+    attribute "tier" {
+        allowed_values = split(",", "free,pro")
+    }
+
+    block_header "range" {
+        body {
+            attribute "from" {}
+            attribute "to" {}
+        }
+
+        validation {
+            condition     = self.from < self.to
+            error_message = "from must be less than to"
+        }
+    }
+}
@@ -0,0 +1,8 @@
+__schema = "validation_rules.schema.hcl"
+
+name = "Payments-Service"
+
+range {
+  from = 5
+  to   = 1
+}
@@ -0,0 +1,141 @@
+package hclschema
+
+import (
+	"fmt"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ValidationRule is a custom condition, written as an HCL expression, that a
+// value must satisfy.
+type ValidationRule struct {
+	// Condition is evaluated with `self` bound to the value being validated:
+	// the attribute's value, or the decoded object of a block. It must
+	// return a bool.
+	Condition hcl.Expression
+	// ErrorMessage is reported when Condition is false.
+	ErrorMessage string
+	// Source is the source text of Condition, which WriteSchema writes out.
+	// It is set for rules parsed from schema files.
+	Source string
+}
+
+// parseValidationRules reads the `validation` blocks among blocks.
+func parseValidationRules(blocks hcl.Blocks, ctx *hcl.EvalContext, refs *schemaRefs) ([]ValidationRule, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	rules := make([]ValidationRule, 0)
+
+	for _, block := range blocks {
+		if block.Type != "validation" {
+			continue
+		}
+		content, d := block.Body.Content(refs.dialect.validation)
+		diags = append(diags, d...)
+		if d.HasErrors() {
+			continue
+		}
+
+		cond := content.Attributes["condition"]
+		refersToSelf := false
+		for _, traversal := range cond.Expr.Variables() {
+			if traversal.RootName() == "self" {
+				refersToSelf = true
+				break
+			}
+		}
+		if !refersToSelf {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "invalid validation condition",
+				Detail:   "the condition must refer to 'self', the value being validated",
+				Subject:  cond.Expr.Range().Ptr(),
+			})
+			continue
+		}
+
+		msg := content.Attributes["error_message"]
+		val, d := msg.Expr.Value(ctx)
+		diags = append(diags, d...)
+		if d.HasErrors() {
+			continue
+		}
+		if val.IsNull() || val.Type() != cty.String || val.AsString() == "" {
+			diags = append(diags, &hcl.Diagnostic{Severity: hcl.DiagError, Summary: "invalid 'error_message'", Detail: "'error_message' must be a non-empty string", Subject: msg.Expr.Range().Ptr()})
+			continue
+		}
+
+		rules = append(rules, ValidationRule{Condition: cond.Expr, ErrorMessage: val.AsString(), Source: string(cond.Expr.Range().SliceBytes(refs.src))})
+	}
+
+	return rules, diags
+}
+
+// checkValidationRules evaluates rules against self and reports the error
+// message of every rule whose condition is false at rng. Rules whose condition
+// can't be evaluated, or is null, because it reads an attribute of self that
+// isn't set are skipped, like the validations of unset attributes.
+func checkValidationRules(rules []ValidationRule, self cty.Value, rng hcl.Range) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if len(rules) == 0 {
+		return diags
+	}
+
+	ctx := selfEvalContext(self)
+	for _, rule := range rules {
+		result, d := rule.Condition.Value(ctx)
+		if (d.HasErrors() || result.IsNull()) && readsUnsetAttribute(rule.Condition, ctx) {
+			continue
+		}
+		if d.HasErrors() {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "failed to evaluate validation condition",
+				Detail:   d.Error(),
+				Subject:  rng.Ptr(),
+			})
+			continue
+		}
+		if !result.IsKnown() {
+			continue
+		}
+		if result.IsNull() || result.Type() != cty.Bool {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "invalid validation condition",
+				Detail:   fmt.Sprintf("the condition must return a bool, got %s", result.Type().FriendlyName()),
+				Subject:  rule.Condition.Range().Ptr(),
+			})
+			continue
+		}
+		if result.False() {
+			diags = append(diags, &hcl.Diagnostic{
+				Severity: hcl.DiagError,
+				Summary:  "validation failed",
+				Detail:   rule.ErrorMessage,
+				Subject:  rng.Ptr(),
+			})
+		}
+	}
+	return diags
+}
+
+// readsUnsetAttribute reports whether expr reads a null attribute of `self`,
+// as omitted optional attributes of decoded blocks are.
+func readsUnsetAttribute(expr hcl.Expression, ctx *hcl.EvalContext) bool {
+	for _, traversal := range expr.Variables() {
+		if traversal.RootName() != "self" {
+			continue
+		}
+		for i := 2; i <= len(traversal); i++ {
+			val, d := traversal[:i].TraverseAbs(ctx)
+			if d.HasErrors() {
+				break
+			}
+			if val.IsNull() {
+				return true
+			}
+		}
+	}
+	return false
+}
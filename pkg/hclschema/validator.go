@@ -0,0 +1,301 @@
+package hclschema
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"sync"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/hclparse"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// ValidatorOptions configures a Validator.
+type ValidatorOptions struct {
+	// Resolver locates the schemas referred to by `__schema` and by schema
+	// imports. It defaults to DefaultResolver().
+	Resolver SchemaResolver
+}
+
+// Validator validates HCL files against the schemas their `__schema` refers
+// to. The schemas are compiled once and cached for the lifetime of the
+// Validator, which is safe for concurrent use.
+type Validator struct {
+	resolver SchemaResolver
+
+	mu sync.Mutex
+	// schemas holds the compiled schemas by canonical URI, and by ref for
+	// refs with a scheme, which resolve the same from any file.
+	schemas map[string]*Schema
+}
+
+// NewValidator returns a Validator configured with opts.
+func NewValidator(opts ValidatorOptions) *Validator {
+	if opts.Resolver == nil {
+		opts.Resolver = DefaultResolver()
+	}
+	return &Validator{resolver: opts.Resolver, schemas: make(map[string]*Schema)}
+}
+
+// Compile compiles the schema ref refers to, relative to baseURI, which may be
+// empty. The error is an hcl.Diagnostics if the schema is invalid, and the
+// warnings of a valid one are returned by its Warnings method.
+func (v *Validator) Compile(ref, baseURI string) (*Schema, error) {
+	return v.CompileContext(context.Background(), ref, baseURI)
+}
+
+// CompileContext is like Compile, but stops loading the schema and its
+// imports once ctx is done.
+func (v *Validator) CompileContext(ctx context.Context, ref, baseURI string) (*Schema, error) {
+	s, diags := v.compile(v.loader(ctx), ref, baseURI)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return s, nil
+}
+
+func (v *Validator) compile(l *schemaLoader, ref, baseURI string) (*Schema, hcl.Diagnostics) {
+	if hasScheme(ref) {
+		if s := v.cached(ref); s != nil {
+			return s, s.warnings
+		}
+	}
+	src, uri, diags := l.open(ref, baseURI)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	if s := v.cached(uri); s != nil {
+		return s, s.warnings
+	}
+
+	fbs, refs, d := l.parse(src, uri, nil)
+	diags = append(diags, d...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	s := newSchema(refs.id, fbs, uri, true)
+	s.warnings = diags
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	// Keep the schema compiled first when two goroutines compile the same
+	// one, so callers always share it.
+	if prev, ok := v.schemas[uri]; ok {
+		s = prev
+	}
+	v.schemas[uri] = s
+	if hasScheme(ref) {
+		v.schemas[ref] = s
+	}
+	return s, s.warnings
+}
+
+func (v *Validator) cached(key string) *Schema {
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	return v.schemas[key]
+}
+
+// ParseSchema parses the schema ref refers to, relative to baseURI, which may
+// be empty.
+func (v *Validator) ParseSchema(ref, baseURI string) (*BlockHeaderAndBodySchema, hcl.Diagnostics) {
+	l := v.loader(context.Background())
+	src, uri, diags := l.open(ref, baseURI)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	fbs, refs, d := l.parse(src, uri, nil)
+	diags = append(diags, d...)
+	if diags.HasErrors() {
+		return nil, diags
+	}
+	return &BlockHeaderAndBodySchema{ID: refs.id, BodySchema: fbs}, diags
+}
+
+// ValidateFile validates the HCL file at filename against the schema its
+// `__schema` refers to, relative to filename. Schema files are validated
+// against their meta-schema, and files without `__schema` are only parsed.
+// Files named `*.json` are parsed as JSON.
+func (v *Validator) ValidateFile(filename string) hcl.Diagnostics {
+	return v.ValidateFileContext(context.Background(), filename)
+}
+
+// ValidateFileContext is like ValidateFile, but stops loading the schema and
+// its imports, and validating the file, once ctx is done. The diagnostics then
+// end with a "validation canceled" or "validation deadline exceeded" error.
+func (v *Validator) ValidateFileContext(ctx context.Context, filename string) hcl.Diagnostics {
+	file, diags := parseConfigFile(filename)
+	if diags.HasErrors() {
+		return diags
+	}
+	return append(diags, v.validateLinked(ctx, file, filename)...)
+}
+
+// ValidateBytes is like ValidateFile, but validates the HCL content src,
+// named filename, instead of reading a file.
+func (v *Validator) ValidateBytes(src []byte, filename string) hcl.Diagnostics {
+	return v.ValidateBytesContext(context.Background(), src, filename)
+}
+
+// ValidateBytesContext is like ValidateBytes, but stops once ctx is done, like
+// ValidateFileContext.
+func (v *Validator) ValidateBytesContext(ctx context.Context, src []byte, filename string) hcl.Diagnostics {
+	file, diags := parseConfig(src, filename)
+	if diags.HasErrors() {
+		return diags
+	}
+	return append(diags, v.validateLinked(ctx, file, filename)...)
+}
+
+func (v *Validator) validateLinked(ctx context.Context, file *hcl.File, filename string) hcl.Diagnostics {
+	l := v.loader(ctx)
+	if strings.HasSuffix(filename, SchemaExtension) {
+		// Schema files are validated against the embedded meta-schema of
+		// their dialect while being parsed.
+		_, _, diags := parseDetectedSchema(file, filename, nil, l)
+		return diags
+	}
+
+	schemaRef, found, diags := linkedSchemaRef(file)
+	if !found || diags.HasErrors() {
+		return diags
+	}
+	s, d := v.compile(l, schemaRef, filename)
+	diags = append(diags, d...)
+	if d.HasErrors() {
+		return diags
+	}
+	return append(diags, s.ValidateContext(ctx, file.Body)...)
+}
+
+// parseConfigFile parses the HCL file at filename, in JSON syntax if its name
+// ends in `.json` and in native syntax otherwise.
+func parseConfigFile(filename string) (*hcl.File, hcl.Diagnostics) {
+	if strings.HasSuffix(filename, ".json") {
+		return hclparse.NewParser().ParseJSONFile(filename)
+	}
+	return hclparse.NewParser().ParseHCLFile(filename)
+}
+
+// parseConfig is like parseConfigFile for the content src of filename.
+func parseConfig(src []byte, filename string) (*hcl.File, hcl.Diagnostics) {
+	if strings.HasSuffix(filename, ".json") {
+		return hclparse.NewParser().ParseJSON(src, filename)
+	}
+	return hclparse.NewParser().ParseHCL(src, filename)
+}
+
+// linkedSchemaRef returns the `__schema` of the root body of file, which must
+// be a literal string, and whether it is set. `__schema` attributes of nested
+// blocks of native syntax files are reported, since they don't link a schema.
+func linkedSchemaRef(file *hcl.File) (string, bool, hcl.Diagnostics) {
+	var diags hcl.Diagnostics
+	if body, ok := file.Body.(*hclsyntax.Body); ok {
+		for _, blk := range body.Blocks {
+			diags = append(diags, nestedSchemaRefs(blk.Body)...)
+		}
+	}
+
+	content, _, _ := file.Body.PartialContent(&hcl.BodySchema{
+		Attributes: []hcl.AttributeSchema{{Name: "__schema"}},
+	})
+	a, ok := content.Attributes["__schema"]
+	if !ok {
+		return "", false, diags
+	}
+	// Without an evaluation context, only literal strings and templates
+	// without interpolations have a value.
+	val, d := a.Expr.Value(nil)
+	if d.HasErrors() || !val.IsKnown() || val.IsNull() || val.Type() != cty.String {
+		return "", true, append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagError,
+			Summary:  "invalid '__schema'",
+			Detail:   "'__schema' must be a literal string referring to the schema of the file",
+			Subject:  a.Expr.Range().Ptr(),
+		})
+	}
+	// Surrounding whitespace, like the final newline of a heredoc, isn't
+	// part of the ref.
+	return strings.TrimSpace(val.AsString()), true, diags
+}
+
+func nestedSchemaRefs(body *hclsyntax.Body) hcl.Diagnostics {
+	var diags hcl.Diagnostics
+	if a, ok := body.Attributes["__schema"]; ok {
+		diags = append(diags, &hcl.Diagnostic{
+			Severity: hcl.DiagWarning,
+			Summary:  "nested '__schema'",
+			Detail:   "'__schema' only links a schema at the root of a file; here it is an ordinary attribute",
+			Subject:  a.NameRange.Ptr(),
+		})
+	}
+	for _, blk := range body.Blocks {
+		diags = append(diags, nestedSchemaRefs(blk.Body)...)
+	}
+	return diags
+}
+
+func (v *Validator) loader(ctx context.Context) *schemaLoader {
+	return &schemaLoader{ctx: ctx, resolver: v.resolver}
+}
+
+// schemaLoader reads the schemas referred to by HCL files and by imports
+// through a resolver.
+type schemaLoader struct {
+	ctx      context.Context
+	resolver SchemaResolver
+}
+
+func defaultSchemaLoader() *schemaLoader {
+	return &schemaLoader{ctx: context.Background(), resolver: DefaultResolver()}
+}
+
+// open resolves ref, found in the file at base, and returns the source of the
+// schema along with its canonical URI. Its diagnostics have no subject.
+func (l *schemaLoader) open(ref, base string) ([]byte, string, hcl.Diagnostics) {
+	if l.ctx.Err() != nil {
+		return nil, "", hcl.Diagnostics{contextDiagnostic(l.ctx)}
+	}
+	rc, uri, err := l.resolver.Resolve(l.ctx, ref, base)
+	if err != nil && l.ctx.Err() != nil {
+		// Resolvers report cancellation in their own words, if at all.
+		return nil, "", hcl.Diagnostics{contextDiagnostic(l.ctx)}
+	}
+	if err != nil {
+		return nil, "", hcl.Diagnostics{{Severity: hcl.DiagError, Summary: "failed to resolve schema", Detail: err.Error()}}
+	}
+	defer rc.Close()
+	src, err := io.ReadAll(rc)
+	if err != nil {
+		return nil, "", hcl.Diagnostics{{Severity: hcl.DiagError, Summary: "failed to read schema", Detail: err.Error()}}
+	}
+	return src, uri, nil
+}
+
+// parse parses the schema source src, loaded from uri.
+func (l *schemaLoader) parse(src []byte, uri string, importing []string) (*FullBodySchema, *schemaRefs, hcl.Diagnostics) {
+	file, diags := hclparse.NewParser().ParseHCL(src, uri)
+	if diags.HasErrors() {
+		return nil, nil, diags
+	}
+	fbs, refs, d := parseDetectedSchema(file, uri, importing, l)
+	return fbs, refs, append(diags, d...)
+}
+
+// contextDiagnostic reports that ctx was done before a schema was loaded or a
+// validation finished.
+func contextDiagnostic(ctx context.Context) *hcl.Diagnostic {
+	summary := "validation canceled"
+	if errors.Is(ctx.Err(), context.DeadlineExceeded) {
+		summary = "validation deadline exceeded"
+	}
+	return &hcl.Diagnostic{
+		Severity: hcl.DiagError,
+		Summary:  summary,
+		Detail:   fmt.Sprintf("validation stopped before completing: %s", context.Cause(ctx)),
+	}
+}
@@ -0,0 +1,291 @@
+package hclschema
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"testing/fstest"
+	"time"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+const paymentsSchema = `__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "schemas://payments/v2"
+
+import "common" {
+    source = "./common"
+}
+
+body {
+    attribute "currency" {
+        required = true
+        type     = string
+    }
+    block_header "tls" {
+        ref = common.block_header.tls
+    }
+}
+`
+
+const paymentsCommonSchema = `__schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
+__id     = "schemas://payments/common"
+
+body {
+    block_header "tls" {
+        id = "tls"
+        body {
+            attribute "cert_file" {
+                required = true
+                type     = string
+            }
+        }
+    }
+}
+`
+
+func TestValidatorCompilesSchemasOnce(t *testing.T) {
+	var resolved atomic.Int32
+	files := MapResolver{"schemas://simple": []byte(simpleSchemaSource(t))}
+	v := NewValidator(ValidatorOptions{Resolver: ResolverFunc(func(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error) {
+		resolved.Add(1)
+		return files.Resolve(ctx, ref, baseURI)
+	})})
+
+	var wg sync.WaitGroup
+	errs := make([]error, 50)
+	for i := range errs {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			src := fmt.Sprintf("__schema = \"schemas://simple\"\nmyattr = %d\n", i)
+			if i%2 == 1 {
+				src = "__schema = \"schemas://simple\"\n"
+			}
+			diags := v.ValidateBytes([]byte(src), fmt.Sprintf("file%d.hcl", i))
+			if diags.HasErrors() != (i%2 == 1) {
+				errs[i] = fmt.Errorf("file%d.hcl: unexpected diagnostics: %v", i, diags)
+			}
+		}()
+	}
+	wg.Wait()
+	for _, err := range errs {
+		if err != nil {
+			t.Error(err)
+		}
+	}
+
+	// Goroutines racing for the first compilation may all resolve the
+	// schema, but later validations use the cached one.
+	before := resolved.Load()
+	if diags := v.ValidateBytes([]byte("__schema = \"schemas://simple\"\nmyattr = 1\n"), "again.hcl"); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	if resolved.Load() != before {
+		t.Fatalf("expected the cached schema to be used")
+	}
+
+	s1, err := v.Compile("schemas://simple", "")
+	if err != nil {
+		t.Fatal(err)
+	}
+	s2, _ := v.Compile("schemas://simple", "")
+	if s1 != s2 {
+		t.Fatalf("expected Compile to return the cached schema")
+	}
+}
+
+func simpleSchemaSource(t *testing.T) string {
+	t.Helper()
+	src, err := os.ReadFile(filepath.Join("testdata", "simple.schema.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return string(src)
+}
+
+func TestValidatorWithCustomResolver(t *testing.T) {
+	v := NewValidator(ValidatorOptions{
+		Resolver: FSResolver{
+			FS: fstest.MapFS{
+				"payments/v2":     {Data: []byte(paymentsSchema)},
+				"payments/common": {Data: []byte(paymentsCommonSchema)},
+			},
+			Prefix: "schemas://",
+		},
+	})
+
+	valid := "__schema = \"schemas://payments/v2\"\ncurrency = \"EUR\"\ntls {\n  cert_file = \"a.pem\"\n}\n"
+	if diags := v.ValidateBytes([]byte(valid), "payments.hcl"); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+
+	invalid := "__schema = \"schemas://payments/v2\"\ntls {}\n"
+	diags := v.ValidateBytes([]byte(invalid), "payments.hcl")
+	if len(diags.Errs()) != 2 {
+		t.Fatalf("expected the missing currency and cert_file, got: %v", diags)
+	}
+
+	diags = v.ValidateBytes([]byte("__schema = \"schemas://billing/v1\"\n"), "billing.hcl")
+	if !diags.HasErrors() || diags[0].Summary != "failed to resolve schema" {
+		t.Fatalf("expected the schema to be reported as not found, got: %v", diags)
+	}
+}
+
+func TestValidatorParseSchema(t *testing.T) {
+	v := NewValidator(ValidatorOptions{Resolver: MapResolver{
+		"schemas://payments/v2":     []byte(paymentsSchema),
+		"schemas://payments/common": []byte(paymentsCommonSchema),
+	}})
+
+	res, diags := v.ParseSchema("v2", "schemas://payments/common")
+	if diags.HasErrors() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+	if res.ID != "schemas://payments/v2" || len(res.BodySchema.Blocks) != 1 {
+		t.Fatalf("unexpected schema: %+v", res)
+	}
+}
+
+func TestValidatorContextDeadlineDuringFetch(t *testing.T) {
+	srv := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		<-r.Context().Done()
+	}))
+	defer srv.Close()
+
+	v := NewValidator(ValidatorOptions{Resolver: &HTTPSResolver{Client: srv.Client()}})
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	start := time.Now()
+	diags := v.ValidateBytesContext(ctx, []byte("__schema = \""+srv.URL+"/a.schema.hcl\"\n"), "a.hcl")
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Fatalf("expected the validation to stop at the deadline, took %s", elapsed)
+	}
+	if len(diags) != 1 || diags[0].Summary != "validation deadline exceeded" {
+		t.Fatalf("expected a deadline diagnostic, got: %v", diags)
+	}
+}
+
+// importKey marks the context whose import resolution is canceled.
+type importKey struct{}
+
+func TestValidatorContextCanceledDuringImport(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.WithValue(context.Background(), importKey{}, true))
+	defer cancel()
+	files := MapResolver{
+		"schemas://payments/v2":     []byte(paymentsSchema),
+		"schemas://payments/common": []byte(paymentsCommonSchema),
+	}
+	v := NewValidator(ValidatorOptions{Resolver: ResolverFunc(func(ctx context.Context, ref, baseURI string) (io.ReadCloser, string, error) {
+		if ref == "./common" && ctx.Value(importKey{}) != nil {
+			cancel()
+			return nil, "", ctx.Err()
+		}
+		return files.Resolve(ctx, ref, baseURI)
+	})})
+
+	diags := v.ValidateBytesContext(ctx, []byte("__schema = \"schemas://payments/v2\"\n"), "payments.hcl")
+	if len(diags) != 1 || diags[0].Summary != "validation canceled" {
+		t.Fatalf("expected only a cancellation diagnostic, got: %v", diags)
+	}
+	if diags[0].Subject == nil || diags[0].Subject.Filename != "schemas://payments/v2" {
+		t.Fatalf("expected the diagnostic to point at the import, got %v", diags[0].Subject)
+	}
+
+	// Canceled compilations aren't cached.
+	if diags := v.ValidateBytes([]byte("__schema = \"schemas://payments/v2\"\ncurrency = \"EUR\"\n"), "payments.hcl"); diags.HasErrors() {
+		t.Fatalf("unexpected errors: %v", diags)
+	}
+}
+
+func TestValidatorLinkedSchemaDetection(t *testing.T) {
+	v := NewValidator(ValidatorOptions{Resolver: MapResolver{"schemas://simple": []byte(simpleSchemaSource(t))}})
+
+	tests := []struct {
+		name     string
+		filename string
+		src      string
+		errors   []string
+		warnings []string
+	}{
+		{
+			name: "comment",
+			src:  "// see __schema = \"other\"\n__schema = \"schemas://simple\"\n",
+			// The schema is found, and myattr is missing.
+			errors: []string{"Missing required argument"},
+		},
+		{
+			name: "string value",
+			src:  "myattr = \"__schema = \\\"other\\\"\"\n__schema = \"schemas://simple\"\n",
+		},
+		{
+			name: "similar name",
+			src:  "my__schema = \"schemas://simple\"\n",
+		},
+		{
+			name:   "heredoc",
+			src:    "__schema = <<EOT\nschemas://simple\nEOT\n",
+			errors: []string{"Missing required argument"},
+		},
+		{
+			name:     "json",
+			filename: "file.json",
+			src:      `{"__schema": "schemas://simple", "myattr": 1}`,
+		},
+		{
+			name:     "json missing attribute",
+			filename: "file.json",
+			src:      `{"__schema": "schemas://simple"}`,
+			errors:   []string{"Missing required argument"},
+		},
+		{
+			name:   "reference",
+			src:    "__schema = local.schema\n",
+			errors: []string{"invalid '__schema'"},
+		},
+		{
+			name:   "template",
+			src:    "__schema = \"schemas://${name}\"\n",
+			errors: []string{"invalid '__schema'"},
+		},
+		{
+			name:     "nested",
+			src:      "__schema = \"schemas://simple\"\nmyattr = 1\ntag \"a\" {\n  __schema = \"other\"\n}\n",
+			errors:   []string{"Unsupported argument"},
+			warnings: []string{"nested '__schema'"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			filename := tt.filename
+			if filename == "" {
+				filename = "file.hcl"
+			}
+			diags := v.ValidateBytes([]byte(tt.src), filename)
+
+			var errs, warnings []string
+			for _, d := range diags {
+				if d.Severity == hcl.DiagError {
+					errs = append(errs, d.Summary)
+				} else {
+					warnings = append(warnings, d.Summary)
+				}
+				if d.Subject == nil || d.Subject.Filename != filename {
+					t.Errorf("expected %q to point into %s, got %v", d.Summary, filename, d.Subject)
+				}
+			}
+			if strings.Join(errs, "; ") != strings.Join(tt.errors, "; ") || strings.Join(warnings, "; ") != strings.Join(tt.warnings, "; ") {
+				t.Fatalf("expected errors %q and warnings %q, got: %v", tt.errors, tt.warnings, diags)
+			}
+		})
+	}
+}
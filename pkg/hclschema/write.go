@@ -0,0 +1,287 @@
+package hclschema
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/hashicorp/hcl/v2"
+	"github.com/hashicorp/hcl/v2/ext/typeexpr"
+	"github.com/hashicorp/hcl/v2/hclsyntax"
+	"github.com/hashicorp/hcl/v2/hclwrite"
+	"github.com/zclconf/go-cty/cty"
+)
+
+// WriteSchema writes s to w as a draft 2025-10 schema file, in the layout of
+// FormatSchema. s.ID is written as the `__id` of the file, and must be set.
+//
+// A body shared by several block_headers, such as the body of a recursive
+// block, is written once, by the first of them, which gets an `id` the
+// others `ref`. The ids of block_headers are kept where possible. Other refs,
+// definitions and extends are written out in full where they're used.
+func WriteSchema(w io.Writer, s *BlockHeaderAndBodySchema) error {
+	if s == nil || s.ID == "" {
+		return errors.New("hclschema: the schema to write must have an ID")
+	}
+
+	sw := &schemaWriter{
+		seen:     make(map[*FullBodySchema]int),
+		written:  make(map[*FullBodySchema]string),
+		reserved: make(map[string]bool),
+		emitted:  make(map[string]bool),
+	}
+	sw.collect(s.BodySchema)
+
+	file := hclwrite.NewEmptyFile()
+	root := file.Body()
+	root.SetAttributeValue("__schema", cty.StringVal(Draft202510))
+	root.SetAttributeValue("__id", cty.StringVal(s.ID))
+	if s.BodySchema != nil {
+		root.AppendNewline()
+		sw.writeBody(root.AppendNewBlock("body", nil).Body(), s.BodySchema)
+	}
+	if sw.err != nil {
+		return sw.err
+	}
+
+	out, diags := FormatSchema(file.Bytes(), s.ID, FormatOptions{})
+	if diags.HasErrors() {
+		return diags
+	}
+	_, err := w.Write(out)
+	return err
+}
+
+type schemaWriter struct {
+	// seen counts the block_headers every body is used by.
+	seen map[*FullBodySchema]int
+	// written maps the shared bodies written so far to their id.
+	written map[*FullBodySchema]string
+	// reserved holds the ids of the block_headers of the schema, and emitted
+	// the ids written so far.
+	reserved map[string]bool
+	emitted  map[string]bool
+
+	err error
+}
+
+// collect counts the uses of every body nested in fbs and reserves the ids of
+// its block_headers.
+func (sw *schemaWriter) collect(fbs *FullBodySchema) {
+	if fbs == nil {
+		return
+	}
+	for _, b := range fbs.Blocks {
+		if b.ID != "" {
+			sw.reserved[b.ID] = true
+		}
+		if b.BodySchema == nil {
+			continue
+		}
+		sw.seen[b.BodySchema]++
+		if sw.seen[b.BodySchema] == 1 {
+			sw.collect(b.BodySchema)
+		}
+	}
+}
+
+// newID returns an unused id for a body shared by blocks of type typ.
+func (sw *schemaWriter) newID(typ string) string {
+	base := strings.Map(func(r rune) rune {
+		if r == '_' || r == '-' || 'a' <= r && r <= 'z' || 'A' <= r && r <= 'Z' || '0' <= r && r <= '9' {
+			return r
+		}
+		return '_'
+	}, typ)
+	if !hclsyntax.ValidIdentifier(base) {
+		base = "body_" + base
+	}
+	id := base
+	for i := 2; sw.reserved[id] || sw.emitted[id]; i++ {
+		id = fmt.Sprintf("%s_%d", base, i)
+	}
+	return id
+}
+
+func (sw *schemaWriter) writeBody(body *hclwrite.Body, fbs *FullBodySchema) {
+	writeMetadata(body, fbs.Metadata)
+	if fbs.AdditionalAttributes {
+		body.SetAttributeValue("additional_attributes", cty.True)
+	}
+	if fbs.AdditionalBlocks {
+		body.SetAttributeValue("additional_blocks", cty.True)
+	}
+	writeAttributeRules(body, fbs.AttributeRules)
+
+	for _, a := range fbs.Attributes {
+		sw.writeAttribute(body.AppendNewBlock("attribute", []string{a.Name}).Body(), a)
+	}
+	for _, p := range fbs.AttributePatterns {
+		sw.writeAttribute(body.AppendNewBlock("attribute_pattern", []string{p.Pattern.String()}).Body(), p.FullAttributeSchema)
+	}
+	for _, b := range fbs.Blocks {
+		sw.writeBlockHeader(body.AppendNewBlock("block_header", []string{b.Type}).Body(), b)
+	}
+}
+
+func (sw *schemaWriter) writeBlockHeader(body *hclwrite.Body, b BlockHeaderAndBodySchema) {
+	if len(b.LabelNames) > 0 {
+		body.SetAttributeValue("label_names", stringTuple(b.LabelNames))
+	}
+	if b.MinItems > 0 {
+		body.SetAttributeValue("min_items", cty.NumberIntVal(int64(b.MinItems)))
+	}
+	if b.MaxItems > 0 {
+		body.SetAttributeValue("max_items", cty.NumberIntVal(int64(b.MaxItems)))
+	}
+	if b.Nesting != "" && b.Nesting != NestingList {
+		body.SetAttributeValue("nesting", cty.StringVal(string(b.Nesting)))
+	}
+	if b.UniqueLabels {
+		body.SetAttributeValue("unique_labels", cty.True)
+	}
+	writeMetadata(body, b.Metadata)
+
+	id := b.ID
+	if sw.emitted[id] {
+		id = ""
+	}
+	switch fbs := b.BodySchema; {
+	case fbs == nil:
+	case sw.written[fbs] != "":
+		if id != "" && id != sw.written[fbs] {
+			sw.emitID(body, id)
+		}
+		writeRef(body, sw.written[fbs])
+	default:
+		if sw.seen[fbs] > 1 {
+			if id == "" {
+				id = sw.newID(b.Type)
+			}
+			sw.written[fbs] = id
+		}
+		if id != "" {
+			sw.emitID(body, id)
+		}
+		sw.writeBody(body.AppendNewBlock("body", nil).Body(), fbs)
+	}
+
+	for _, l := range b.Labels {
+		writeValueConstraints(body.AppendNewBlock("label", []string{l.Name}).Body(), l.ValueConstraints)
+	}
+	for _, m := range b.MatchLabels {
+		mb := body.AppendNewBlock("match_label", nil).Body()
+		mb.SetAttributeValue("index", cty.NumberIntVal(int64(m.Index)))
+		mb.SetAttributeValue("value", cty.StringVal(m.Value))
+	}
+	sw.writeValidations(body, b.Validations, fmt.Sprintf("block_header '%s'", b.Type))
+}
+
+// writeRef refers to the block_header with the given id, with a traversal if
+// the id is an identifier.
+func writeRef(body *hclwrite.Body, id string) {
+	if !hclsyntax.ValidIdentifier(id) {
+		body.SetAttributeValue("ref", cty.StringVal("block_header."+id))
+		return
+	}
+	body.SetAttributeTraversal("ref", hcl.Traversal{hcl.TraverseRoot{Name: "block_header"}, hcl.TraverseAttr{Name: id}})
+}
+
+func (sw *schemaWriter) emitID(body *hclwrite.Body, id string) {
+	sw.emitted[id] = true
+	body.SetAttributeValue("id", cty.StringVal(id))
+}
+
+func (sw *schemaWriter) writeAttribute(body *hclwrite.Body, a FullAttributeSchema) {
+	if a.Required {
+		body.SetAttributeValue("required", cty.True)
+	}
+	if a.Type != cty.NilType {
+		body.SetAttributeRaw("type", hclwrite.Tokens{{Type: hclsyntax.TokenIdent, Bytes: []byte(typeexpr.TypeString(a.Type))}})
+	}
+	if !a.Default.IsNull() {
+		body.SetAttributeValue("default", a.Default)
+	}
+	writeAttributeRules(body, a.AttributeRules)
+	writeValueConstraints(body, a.ValueConstraints)
+	writeMetadata(body, a.Metadata)
+	sw.writeValidations(body, a.Validations, fmt.Sprintf("attribute '%s'", a.Name))
+}
+
+func (sw *schemaWriter) writeValidations(body *hclwrite.Body, rules []ValidationRule, owner string) {
+	for _, rule := range rules {
+		if rule.Source == "" {
+			if sw.err == nil {
+				sw.err = fmt.Errorf("hclschema: a validation of %s has no condition source", owner)
+			}
+			continue
+		}
+		vb := body.AppendNewBlock("validation", nil).Body()
+		vb.SetAttributeRaw("condition", hclwrite.Tokens{{Type: hclsyntax.TokenIdent, Bytes: []byte(rule.Source)}})
+		vb.SetAttributeValue("error_message", cty.StringVal(rule.ErrorMessage))
+	}
+}
+
+func writeValueConstraints(body *hclwrite.Body, vc ValueConstraints) {
+	if vc.AllowedValues != nil {
+		body.SetAttributeValue("allowed_values", cty.TupleVal(vc.AllowedValues))
+	}
+	if vc.Pattern != nil {
+		body.SetAttributeValue("pattern", cty.StringVal(vc.Pattern.String()))
+	}
+	if vc.Min != nil {
+		body.SetAttributeValue("min", cty.NumberVal(vc.Min))
+	}
+	if vc.Max != nil {
+		body.SetAttributeValue("max", cty.NumberVal(vc.Max))
+	}
+	if vc.MinLength != nil {
+		body.SetAttributeValue("min_length", cty.NumberIntVal(int64(*vc.MinLength)))
+	}
+	if vc.MaxLength != nil {
+		body.SetAttributeValue("max_length", cty.NumberIntVal(int64(*vc.MaxLength)))
+	}
+}
+
+func writeAttributeRules(body *hclwrite.Body, r AttributeRules) {
+	write := func(name string, groups [][]string) {
+		switch len(groups) {
+		case 0:
+		case 1:
+			body.SetAttributeValue(name, stringTuple(groups[0]))
+		default:
+			lists := make([]cty.Value, len(groups))
+			for i, g := range groups {
+				lists[i] = stringTuple(g)
+			}
+			body.SetAttributeValue(name, cty.TupleVal(lists))
+		}
+	}
+	write("one_of", r.OneOf)
+	write("exactly_one_of", r.ExactlyOneOf)
+	write("conflicts_with", r.ConflictsWith)
+	write("required_with", r.RequiredWith)
+}
+
+func writeMetadata(body *hclwrite.Body, md Metadata) {
+	if md.Description != "" {
+		body.SetAttributeValue("description", cty.StringVal(md.Description))
+	}
+	if md.Examples != nil {
+		body.SetAttributeValue("examples", cty.TupleVal(md.Examples))
+	}
+	if md.DeprecationMessage != "" {
+		body.SetAttributeValue("deprecation_message", cty.StringVal(md.DeprecationMessage))
+	} else if md.Deprecated {
+		body.SetAttributeValue("deprecated", cty.True)
+	}
+}
+
+func stringTuple(names []string) cty.Value {
+	elems := make([]cty.Value, len(names))
+	for i, name := range names {
+		elems[i] = cty.StringVal(name)
+	}
+	return cty.TupleVal(elems)
+}
@@ -0,0 +1,99 @@
+package hclschema
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/hashicorp/hcl/v2"
+)
+
+// writeAndReparse writes s to a temporary schema file and parses it again.
+func writeAndReparse(t *testing.T, s *BlockHeaderAndBodySchema) ([]byte, *BlockHeaderAndBodySchema) {
+	t.Helper()
+	var buf bytes.Buffer
+	if err := WriteSchema(&buf, s); err != nil {
+		t.Fatalf("failed to write schema: %v", err)
+	}
+	path := filepath.Join(t.TempDir(), "written.schema.hcl")
+	if err := os.WriteFile(path, buf.Bytes(), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	res, diags := ParseSchemaFile(path)
+	if diags.HasErrors() {
+		t.Fatalf("written schema doesn't parse: %v\n%s", diags, buf.String())
+	}
+	return buf.Bytes(), res
+}
+
+func TestWriteSchema_RoundTrip(t *testing.T) {
+	paths, err := filepath.Glob(filepath.Join("testdata", "*.schema.hcl"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	paths = append(paths, filepath.Join("..", "..", "schema", "draft", "2025-10", ".schema.hcl"))
+
+	for _, path := range paths {
+		s, diags := ParseSchemaFile(path)
+		if diags.HasErrors() {
+			continue
+		}
+		t.Run(filepath.Base(path), func(t *testing.T) {
+			written, reparsed := writeAndReparse(t, s)
+			if reparsed.ID != s.ID {
+				t.Fatalf("expected ID %q, got %q", s.ID, reparsed.ID)
+			}
+			again, _ := writeAndReparse(t, reparsed)
+			if !bytes.Equal(written, again) {
+				t.Fatalf("writing the written schema changed it:\n%s\n---\n%s", written, again)
+			}
+		})
+	}
+}
+
+func TestWriteSchema_SharedBodies(t *testing.T) {
+	s, diags := ParseSchemaFile(filepath.Join("..", "..", "schema", "draft", "2025-10", ".schema.hcl"))
+	if diags.HasErrors() {
+		t.Fatalf("failed to parse the meta-schema: %v", diags)
+	}
+	written, reparsed := writeAndReparse(t, s)
+	if !strings.Contains(string(written), `id = "bodyRef"`) || !strings.Contains(string(written), "ref = block_header.bodyRef") {
+		t.Fatalf("expected the recursive body to be shared with an id and a ref:\n%s", written)
+	}
+
+	body := reparsed.BodySchema.Blocks[1]
+	blockHeader := body.BodySchema.Blocks[0]
+	nested := blockHeader.BodySchema.findBlockDef(&hcl.Block{Type: "body"}).BodySchema
+	if body.Type != "body" || nested != body.BodySchema {
+		t.Fatalf("expected the nested body block to share the body of the root body block")
+	}
+}
+
+func TestWriteSchema_GeneratesIDs(t *testing.T) {
+	shared := &FullBodySchema{Attributes: []FullAttributeSchema{{AttributeSchema: hcl.AttributeSchema{Name: "name"}}}}
+	shared.Blocks = []BlockHeaderAndBodySchema{{BlockHeaderSchema: hcl.BlockHeaderSchema{Type: "child"}, BodySchema: shared}}
+	s := &BlockHeaderAndBodySchema{
+		ID: "local://generated",
+		BodySchema: &FullBodySchema{Blocks: []BlockHeaderAndBodySchema{
+			{BlockHeaderSchema: hcl.BlockHeaderSchema{Type: "node"}, BodySchema: shared},
+			{BlockHeaderSchema: hcl.BlockHeaderSchema{Type: "other"}, BodySchema: shared},
+		}},
+	}
+
+	written, reparsed := writeAndReparse(t, s)
+	if strings.Count(string(written), `attribute "name"`) != 1 {
+		t.Fatalf("expected the shared body to be written once:\n%s", written)
+	}
+	node := reparsed.BodySchema.Blocks[0].BodySchema
+	if reparsed.BodySchema.Blocks[1].BodySchema != node || node.Blocks[0].BodySchema != node {
+		t.Fatalf("expected every block to share the same body:\n%s", written)
+	}
+}
+
+func TestWriteSchema_MissingID(t *testing.T) {
+	if err := WriteSchema(&bytes.Buffer{}, &BlockHeaderAndBodySchema{BodySchema: &FullBodySchema{}}); err == nil {
+		t.Fatalf("expected an error writing a schema without an ID")
+	}
+}
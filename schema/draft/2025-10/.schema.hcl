@@ -2,11 +2,53 @@ __schema = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/mai
 __id     = "https://raw.githubusercontent.com/avestura/hcl-schema/refs/heads/main/schema/draft/2025-10/.schema.hcl"
 
 body {
-    attribute "__schema" {}
-    attribute "__id" {}
+    attribute "__schema" {
+        required = true
+    }
+    attribute "__id" {
+        required = true
+    }
+    block_header "import" {
+        label_names = ["name"]
+        body {
+            attribute "source" {
+                required = true
+            }
+        }
+    }
     block_header "body" {
         id = "bodyRef"
         body {
+            attribute "description" {
+                required = false
+            }
+            attribute "examples" {
+                required = false
+            }
+            attribute "deprecated" {
+                required = false
+            }
+            attribute "deprecation_message" {
+                required = false
+            }
+            attribute "additional_attributes" {
+                required = false
+            }
+            attribute "additional_blocks" {
+                required = false
+            }
+            attribute "one_of" {
+                required = false
+            }
+            attribute "exactly_one_of" {
+                required = false
+            }
+            attribute "conflicts_with" {
+                required = false
+            }
+            attribute "required_with" {
+                required = false
+            }
             block_header "block_header" {
                 label_names = ["block_header_type"]
                 body {
@@ -19,20 +61,153 @@ body {
                     attribute "id" {
                         required = false
                     }
+                    attribute "extends" {
+                        required = false
+                    }
+                    attribute "remove" {
+                        required = false
+                    }
+                    attribute "min_items" {
+                        required = false
+                    }
+                    attribute "max_items" {
+                        required = false
+                    }
+                    attribute "nesting" {
+                        required = false
+                    }
+                    attribute "unique_labels" {
+                        required = false
+                    }
+                    attribute "description" {
+                        required = false
+                    }
+                    attribute "examples" {
+                        required = false
+                    }
+                    attribute "deprecated" {
+                        required = false
+                    }
+                    attribute "deprecation_message" {
+                        required = false
+                    }
 
                     block_header "body" {
                         ref = block_header.bodyRef
                     }
+                    block_header "label" {
+                        label_names = ["label_name"]
+                        body {
+                            attribute "pattern" {
+                                required = false
+                            }
+                            attribute "allowed_values" {
+                                required = false
+                            }
+                        }
+                    }
+                    block_header "validation" {
+                        id = "validationRef"
+                        body {
+                            attribute "condition" {
+                                required = true
+                            }
+                            attribute "error_message" {
+                                required = true
+                            }
+                        }
+                    }
+                    block_header "match_label" {
+                        body {
+                            attribute "index" {
+                                required = true
+                            }
+                            attribute "value" {
+                                required = true
+                            }
+                        }
+                    }
                 }
             }
             block_header "attribute" {
+                id = "attributeRef"
                 label_names = ["attribute_name"]
                 body {
+                    attribute "ref" {
+                        required = false
+                    }
                     attribute "required" {
                         required = false
                     }
+                    attribute "type" {
+                        required = false
+                    }
+                    attribute "default" {
+                        required = false
+                    }
+                    attribute "one_of" {
+                        required = false
+                    }
+                    attribute "exactly_one_of" {
+                        required = false
+                    }
+                    attribute "conflicts_with" {
+                        required = false
+                    }
+                    attribute "required_with" {
+                        required = false
+                    }
+                    attribute "allowed_values" {
+                        required = false
+                    }
+                    attribute "pattern" {
+                        required = false
+                    }
+                    attribute "min" {
+                        required = false
+                    }
+                    attribute "max" {
+                        required = false
+                    }
+                    attribute "min_length" {
+                        required = false
+                    }
+                    attribute "max_length" {
+                        required = false
+                    }
+                    attribute "description" {
+                        required = false
+                    }
+                    attribute "examples" {
+                        required = false
+                    }
+                    attribute "deprecated" {
+                        required = false
+                    }
+                    attribute "deprecation_message" {
+                        required = false
+                    }
+                    block_header "validation" {
+                        ref = block_header.validationRef
+                    }
                 }
             }
+            block_header "attribute_pattern" {
+                label_names = ["pattern"]
+                ref = block_header.attributeRef
+            }
+        }
+    }
+    block_header "definitions" {
+        body {
+            block_header "body" {
+                label_names = ["name"]
+                ref = block_header.bodyRef
+            }
+            block_header "attribute" {
+                label_names = ["attribute_name"]
+                ref = block_header.attributeRef
+            }
         }
     }
 }
\ No newline at end of file
@@ -0,0 +1,10 @@
+// Package schema embeds the root schemas of the schema language, which
+// describe schema files and select their dialect through `__schema`.
+package schema
+
+import _ "embed"
+
+// Draft202510 is the root schema of draft 2025-10.
+//
+//go:embed draft/2025-10/.schema.hcl
+var Draft202510 []byte
@@ -11,6 +11,16 @@ interface OutDiagnostic {
 	endCol: number;
 	severity: 'error' | 'warning' | 'info';
 	message: string;
+	related?: OutRelated[];
+}
+
+interface OutRelated {
+	file: string;
+	startLine: number;
+	startCol: number;
+	endLine: number;
+	endCol: number;
+	message: string;
 }
 
 let diagnosticCollection: vscode.DiagnosticCollection;
@@ -78,6 +88,12 @@ function scheduleValidate(document: vscode.TextDocument) {
 			for (const d of out) {
 				const range = new vscode.Range(d.startLine, d.startCol, d.endLine, d.endCol);
 				const diag = new vscode.Diagnostic(range, d.message, toVSCodeSeverity(d.severity));
+				if (d.related && d.related.length > 0) {
+					diag.relatedInformation = d.related.map(r => new vscode.DiagnosticRelatedInformation(
+						new vscode.Location(vscode.Uri.file(r.file), new vscode.Range(r.startLine, r.startCol, r.endLine, r.endCol)),
+						r.message,
+					));
+				}
 				diagnostics.push(diag);
 			}
 			diagnosticCollection.set(document.uri, diagnostics);